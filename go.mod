@@ -8,10 +8,13 @@ require (
 	github.com/aws/aws-sdk-go v1.30.12 // indirect
 	github.com/cenkalti/backoff v2.1.1+incompatible
 	github.com/ghodss/yaml v1.0.0
+	github.com/hashicorp/go-cty v1.4.1-0.20200414143053-d3edf31b6320
+	github.com/hashicorp/go-version v1.2.1
 	github.com/hashicorp/hcl/v2 v2.6.0 // indirect
 	github.com/hashicorp/terraform-plugin-sdk/v2 v2.0.4
 	github.com/icza/dyno v0.0.0-20180601094105-0c96289f9585
 	github.com/mitchellh/go-homedir v1.1.0
+	golang.org/x/net v0.0.0-20200707034311-ab3426394381
 	gopkg.in/yaml.v2 v2.2.8
 	k8s.io/api v0.17.12
 	k8s.io/apimachinery v0.17.12
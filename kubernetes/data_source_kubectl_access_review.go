@@ -0,0 +1,332 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	restclient "k8s.io/client-go/rest"
+)
+
+func dataSourceKubectlAccessReviewSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"kubeconfig": kubeconfigOverrideSchema(),
+		"user": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "User to review the access of instead of the identity of the provider, requires permission to create subjectaccessreviews.",
+		},
+		"groups": &schema.Schema{
+			Type:        schema.TypeList,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Groups to review the access of instead of the identity of the provider, requires permission to create subjectaccessreviews.",
+		},
+		"fail_if_denied": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Fail the read when any of the checks is denied.",
+		},
+		"check": &schema.Schema{
+			Type:        schema.TypeList,
+			Optional:    true,
+			Description: "Access to check, like kubectl auth can-i.",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"verb": &schema.Schema{
+						Type:     schema.TypeString,
+						Required: true,
+					},
+					"group": &schema.Schema{
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"resource": &schema.Schema{
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"subresource": &schema.Schema{
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"namespace": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Namespace of the resource, all namespaces when empty.",
+					},
+					"name": &schema.Schema{
+						Type:     schema.TypeString,
+						Optional: true,
+					},
+					"non_resource_url": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						Description: "Non-resource URL to check instead of a resource, e.g. /healthz.",
+					},
+					"allowed": &schema.Schema{
+						Type:     schema.TypeBool,
+						Computed: true,
+					},
+					"denied": &schema.Schema{
+						Type:     schema.TypeBool,
+						Computed: true,
+					},
+					"reason": &schema.Schema{
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"evaluation_error": &schema.Schema{
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+				},
+			},
+		},
+		"all_allowed": &schema.Schema{
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"rules_namespace": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Namespace to list the rules of the identity of the provider in, like kubectl auth can-i --list.",
+		},
+		"resource_rules": &schema.Schema{
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"verbs": &schema.Schema{
+						Type:     schema.TypeList,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+					"api_groups": &schema.Schema{
+						Type:     schema.TypeList,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+					"resources": &schema.Schema{
+						Type:     schema.TypeList,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+					"resource_names": &schema.Schema{
+						Type:     schema.TypeList,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+				},
+			},
+		},
+		"non_resource_rules": &schema.Schema{
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"verbs": &schema.Schema{
+						Type:     schema.TypeList,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+					"non_resource_urls": &schema.Schema{
+						Type:     schema.TypeList,
+						Computed: true,
+						Elem:     &schema.Schema{Type: schema.TypeString},
+					},
+				},
+			},
+		},
+		"rules_incomplete": &schema.Schema{
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"rules_evaluation_error": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+	}
+}
+
+func dataSourceKubectlAccessReview() *schema.Resource {
+	dataSourceSchema := dataSourceKubectlAccessReviewSchema()
+	dataSourceSchema["timeouts"] = dataSourceTimeoutsSchema()
+
+	return &schema.Resource{
+		ReadContext: readWithDataSourceTimeout(dataSourceKubectlAccessReviewRead),
+		Schema:      dataSourceSchema,
+	}
+}
+
+func dataSourceKubectlAccessReviewRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := kubeProviderFromResourceData(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if skip, err := provider.skipReadForUnknownConfig(d); skip {
+		return diag.FromErr(err)
+	}
+	client, err := provider.MainClientset()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	restClient := client.AuthorizationV1().RESTClient()
+
+	user := d.Get("user").(string)
+	groups := expandStringSlice(d.Get("groups").([]interface{}))
+	checks := []interface{}{}
+	denied := []string{}
+	for _, v := range d.Get("check").([]interface{}) {
+		check, ok := v.(map[string]interface{})
+		if !ok {
+			return diag.Errorf("failed to parse check")
+		}
+		resourceAttributes, nonResourceAttributes := expandAccessReviewAttributes(check)
+
+		var status authorizationv1.SubjectAccessReviewStatus
+		err = provider.withReadRetry(ctx, "review access", func() (err error) {
+			status, err = reviewAccess(ctx, restClient, user, groups, resourceAttributes, nonResourceAttributes)
+			return err
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		check["allowed"] = status.Allowed
+		check["denied"] = status.Denied
+		check["reason"] = status.Reason
+		check["evaluation_error"] = status.EvaluationError
+		checks = append(checks, check)
+		if !status.Allowed {
+			denied = append(denied, describeAccessReviewCheck(check))
+		}
+	}
+	if len(denied) > 0 && d.Get("fail_if_denied").(bool) {
+		return diag.Errorf("access denied to %s", strings.Join(denied, ", "))
+	}
+
+	properties := map[string]interface{}{
+		"check":       checks,
+		"all_allowed": len(denied) == 0,
+	}
+
+	if v, ok := d.GetOk("rules_namespace"); ok {
+		review := &authorizationv1.SelfSubjectRulesReview{
+			Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: v.(string)},
+		}
+		err = provider.withReadRetry(ctx, "review rules", func() error {
+			return createWithContext(ctx, restClient, "selfsubjectrulesreviews", review, review)
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+
+		resourceRules := []interface{}{}
+		for _, rule := range review.Status.ResourceRules {
+			resourceRules = append(resourceRules, map[string]interface{}{
+				"verbs":          rule.Verbs,
+				"api_groups":     rule.APIGroups,
+				"resources":      rule.Resources,
+				"resource_names": rule.ResourceNames,
+			})
+		}
+		nonResourceRules := []interface{}{}
+		for _, rule := range review.Status.NonResourceRules {
+			nonResourceRules = append(nonResourceRules, map[string]interface{}{
+				"verbs":             rule.Verbs,
+				"non_resource_urls": rule.NonResourceURLs,
+			})
+		}
+		properties["resource_rules"] = resourceRules
+		properties["non_resource_rules"] = nonResourceRules
+		properties["rules_incomplete"] = review.Status.Incomplete
+		properties["rules_evaluation_error"] = review.Status.EvaluationError
+	}
+
+	for k, v := range properties {
+		err := d.Set(k, v)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	props, err := yaml.Marshal(properties)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256(props)))
+	return nil
+}
+
+// expandAccessReviewAttributes returns the attributes of the access check, which is
+// about a non-resource URL when one is set.
+func expandAccessReviewAttributes(check map[string]interface{}) (*authorizationv1.ResourceAttributes, *authorizationv1.NonResourceAttributes) {
+	if url := check["non_resource_url"].(string); url != "" {
+		return nil, &authorizationv1.NonResourceAttributes{
+			Path: url,
+			Verb: check["verb"].(string),
+		}
+	}
+	return &authorizationv1.ResourceAttributes{
+		Namespace:   check["namespace"].(string),
+		Verb:        check["verb"].(string),
+		Group:       check["group"].(string),
+		Resource:    check["resource"].(string),
+		Subresource: check["subresource"].(string),
+		Name:        check["name"].(string),
+	}, nil
+}
+
+// reviewAccess reviews the access of the identity of the provider, or of the user
+// and groups when set.
+func reviewAccess(ctx context.Context, client restclient.Interface, user string, groups []string, resourceAttributes *authorizationv1.ResourceAttributes, nonResourceAttributes *authorizationv1.NonResourceAttributes) (authorizationv1.SubjectAccessReviewStatus, error) {
+	if user == "" && len(groups) == 0 {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes:    resourceAttributes,
+				NonResourceAttributes: nonResourceAttributes,
+			},
+		}
+		err := createWithContext(ctx, client, "selfsubjectaccessreviews", review, review)
+		return review.Status, err
+	}
+
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes:    resourceAttributes,
+			NonResourceAttributes: nonResourceAttributes,
+			User:                  user,
+			Groups:                groups,
+		},
+	}
+	err := createWithContext(ctx, client, "subjectaccessreviews", review, review)
+	return review.Status, err
+}
+
+// describeAccessReviewCheck describes the access check like the arguments of kubectl auth can-i.
+func describeAccessReviewCheck(check map[string]interface{}) string {
+	if url := check["non_resource_url"].(string); url != "" {
+		return fmt.Sprintf("%s %s", check["verb"], url)
+	}
+
+	resource := check["resource"].(string)
+	if group := check["group"].(string); group != "" {
+		resource += "." + group
+	}
+	if name := check["name"].(string); name != "" {
+		resource += "/" + name
+	}
+	if subresource := check["subresource"].(string); subresource != "" {
+		resource += " --subresource " + subresource
+	}
+	if namespace := check["namespace"].(string); namespace != "" {
+		resource += " -n " + namespace
+	}
+	return fmt.Sprintf("%s %s", check["verb"], resource)
+}
@@ -0,0 +1,84 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+func Test_reviewAccess(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		review := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			t.Fatal(err)
+		}
+		review["status"] = authorizationv1.SubjectAccessReviewStatus{Allowed: true, Reason: "bound"}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(review)
+	}))
+	defer server.Close()
+
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes := &authorizationv1.ResourceAttributes{Verb: "create", Resource: "deployments", Group: "apps", Namespace: "apps"}
+
+	tests := []struct {
+		name   string
+		user   string
+		groups []string
+		then   string
+	}{
+		{"validate the identity of the provider is reviewed by default", "", nil, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews"},
+		{"validate a user is reviewed as a subject", "deployer", nil, "/apis/authorization.k8s.io/v1/subjectaccessreviews"},
+		{"validate groups are reviewed as a subject", "", []string{"ci"}, "/apis/authorization.k8s.io/v1/subjectaccessreviews"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := reviewAccess(context.Background(), client.AuthorizationV1().RESTClient(), tt.user, tt.groups, attributes, nil)
+			if err != nil {
+				t.Fatalf("reviewAccess() error = %v", err)
+			}
+			if path != tt.then {
+				t.Errorf("reviewAccess() requested %s, want %s", path, tt.then)
+			}
+			if !status.Allowed || status.Reason != "bound" {
+				t.Errorf("reviewAccess() = %+v, want the status of the review", status)
+			}
+		})
+	}
+}
+
+func Test_describeAccessReviewCheck(t *testing.T) {
+	tests := []struct {
+		given map[string]interface{}
+		then  string
+	}{
+		{
+			map[string]interface{}{"verb": "create", "group": "apps", "resource": "deployments", "subresource": "", "namespace": "apps", "name": "", "non_resource_url": ""},
+			"create deployments.apps -n apps",
+		},
+		{
+			map[string]interface{}{"verb": "get", "group": "", "resource": "pods", "subresource": "log", "namespace": "", "name": "web", "non_resource_url": ""},
+			"get pods/web --subresource log",
+		},
+		{
+			map[string]interface{}{"verb": "get", "group": "", "resource": "", "subresource": "", "namespace": "", "name": "", "non_resource_url": "/healthz"},
+			"get /healthz",
+		},
+	}
+	for _, tt := range tests {
+		if got := describeAccessReviewCheck(tt.given); got != tt.then {
+			t.Errorf("describeAccessReviewCheck() = %q, want %q", got, tt.then)
+		}
+	}
+}
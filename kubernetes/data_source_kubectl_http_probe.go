@@ -0,0 +1,195 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	restclient "k8s.io/client-go/rest"
+)
+
+const defaultHTTPProbeMaxBodySize = 1024 * 1024
+
+func dataSourceKubectlHTTPProbeSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"kubeconfig":          kubeconfigOverrideSchema(),
+		"namespace":           namespaceSchema(),
+		"effective_namespace": effectiveNamespaceSchema(),
+		"service": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"service", "pod"},
+			Description:  "Name of the service to probe.",
+		},
+		"pod": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Name of the pod to probe.",
+		},
+		"port": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Name or number of the port to probe, defaults to the first port of the service or port 80 of the pod.",
+		},
+		"scheme": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "http",
+			ValidateFunc: validation.StringInSlice([]string{"http", "https"}, false),
+			Description:  "Scheme the API server uses to reach the endpoint. The API server doesn't verify the certificate of https endpoints.",
+		},
+		"path": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "/",
+		},
+		"query": &schema.Schema{
+			Type:     schema.TypeMap,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"request_headers": &schema.Schema{
+			Type:     schema.TypeMap,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"max_body_size": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      defaultHTTPProbeMaxBodySize,
+			ValidateFunc: validation.IntAtLeast(1),
+			Description:  "Size in bytes of the body above which the read fails, as the body is kept in the state.",
+		},
+		"url": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"status_code": &schema.Schema{
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Status code of the response, which may come from the API server, e.g. 503 when the service has no ready endpoints.",
+		},
+		"headers": &schema.Schema{
+			Type:        schema.TypeMap,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Headers of the response, multiple values being comma separated.",
+		},
+		"body": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+	}
+}
+
+func dataSourceKubectlHTTPProbe() *schema.Resource {
+	dataSourceSchema := dataSourceKubectlHTTPProbeSchema()
+	dataSourceSchema["timeouts"] = dataSourceTimeoutsSchema()
+
+	return &schema.Resource{
+		ReadContext: readWithDataSourceTimeout(dataSourceKubectlHTTPProbeRead),
+		Schema:      dataSourceSchema,
+	}
+}
+
+func dataSourceKubectlHTTPProbeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := kubeProviderFromResourceData(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if skip, err := provider.skipReadForUnknownConfig(d); skip {
+		return diag.FromErr(err)
+	}
+	client, err := provider.MainClientset()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	// The REST client turns error statuses into errors, while the probe reports them
+	config := provider.RestConfig
+	transport, err := restclient.TransportFor(&config)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	httpClient := &http.Client{Transport: transport, Timeout: config.Timeout}
+
+	namespace := namespaceFromResourceData(d, provider)
+	resource, name := "services", d.Get("service").(string)
+	if v, ok := d.GetOk("pod"); ok {
+		resource, name = "pods", v.(string)
+	}
+	probeURL := proxyURL(client.CoreV1().RESTClient(), namespace, resource, name, d.Get("scheme").(string), d.Get("port").(string), d.Get("path").(string))
+	query := probeURL.Query()
+	for k, v := range d.Get("query").(map[string]interface{}) {
+		query.Set(k, v.(string))
+	}
+	probeURL.RawQuery = query.Encode()
+
+	maxBodySize := d.Get("max_body_size").(int)
+	var response *http.Response
+	var body []byte
+	err = provider.withReadRetry(ctx, "probe "+probeURL.Path, func() error {
+		request, err := http.NewRequest(http.MethodGet, probeURL.String(), nil)
+		if err != nil {
+			return err
+		}
+		for k, v := range d.Get("request_headers").(map[string]interface{}) {
+			request.Header.Set(k, v.(string))
+		}
+		response, err = httpClient.Do(request.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		body, err = ioutil.ReadAll(io.LimitReader(response.Body, int64(maxBodySize)+1))
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to probe %s: %w", probeURL.Path, err))
+	}
+	if len(body) > maxBodySize {
+		return diag.Errorf("the body of %s is larger than max_body_size of %d bytes", probeURL.Path, maxBodySize)
+	}
+
+	headers := map[string]string{}
+	for k, v := range response.Header {
+		headers[k] = strings.Join(v, ", ")
+	}
+	_ = d.Set("effective_namespace", namespace)
+	_ = d.Set("url", probeURL.String())
+	_ = d.Set("status_code", response.StatusCode)
+	_ = d.Set("headers", headers)
+	_ = d.Set("body", string(body))
+
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%s", probeURL, response.StatusCode, body)))))
+	return nil
+}
+
+// proxyURL returns the URL of the path of the service or pod through the proxy
+// subresource of the API server, e.g. /api/v1/namespaces/x/services/https:name:port/proxy/path.
+// The path is appended as is, keeping any trailing slash the endpoint may expect.
+func proxyURL(client restclient.Interface, namespace, resource, name, scheme, port, path string) *url.URL {
+	target := name
+	if port != "" {
+		target += ":" + port
+	}
+	if scheme == "https" {
+		target = scheme + ":" + target
+	}
+
+	u := client.Get().
+		Namespace(namespace).
+		Resource(resource).
+		Name(target).
+		SubResource("proxy").
+		URL()
+	u.Path += "/" + strings.TrimPrefix(path, "/")
+	return u
+}
@@ -0,0 +1,70 @@
+package kubernetes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+func Test_proxyURL(t *testing.T) {
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		resource, name, scheme, port, path string
+		then                               string
+	}{
+		{"services", "web", "http", "", "/", "https://example.com/api/v1/namespaces/apps/services/web/proxy/"},
+		{"services", "vault", "https", "8200", "/v1/sys/health", "https://example.com/api/v1/namespaces/apps/services/https:vault:8200/proxy/v1/sys/health"},
+		{"pods", "web-0", "http", "metrics", "status/", "https://example.com/api/v1/namespaces/apps/pods/web-0:metrics/proxy/status/"},
+	}
+	for _, tt := range tests {
+		if got := proxyURL(client.CoreV1().RESTClient(), "apps", tt.resource, tt.name, tt.scheme, tt.port, tt.path).String(); got != tt.then {
+			t.Errorf("proxyURL() = %s, want %s", got, tt.then)
+		}
+	}
+}
+
+func Test_dataSourceKubectlHTTPProbeRead(t *testing.T) {
+	var requested *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("sealed"))
+	}))
+	defer server.Close()
+
+	provider := newKubeProvider(&restclient.Config{Host: server.URL})
+	provider.namespace = "default"
+	d := schema.TestResourceDataRaw(t, dataSourceKubectlHTTPProbe().Schema, map[string]interface{}{
+		"service":         "vault",
+		"port":            "8200",
+		"path":            "/v1/sys/health",
+		"query":           map[string]interface{}{"standbyok": "true"},
+		"request_headers": map[string]interface{}{"X-Vault-Request": "true"},
+	})
+	if diags := dataSourceKubectlHTTPProbeRead(context.Background(), d, provider); diags.HasError() {
+		t.Fatalf("dataSourceKubectlHTTPProbeRead() = %v", diags)
+	}
+
+	if requested.URL.Path != "/api/v1/namespaces/default/services/vault:8200/proxy/v1/sys/health" || requested.URL.Query().Get("standbyok") != "true" {
+		t.Errorf("requested %s, want the service proxy with the query", requested.URL)
+	}
+	if requested.Header.Get("X-Vault-Request") != "true" {
+		t.Errorf("requested headers %v, want the request headers", requested.Header)
+	}
+	if d.Get("status_code").(int) != http.StatusServiceUnavailable || d.Get("body").(string) != "sealed" || d.Get("headers.Content-Type").(string) != "text/plain" {
+		t.Errorf("probe = %d %q with headers %v, want the unavailable response", d.Get("status_code"), d.Get("body"), d.Get("headers"))
+	}
+	if d.Get("namespace").(string) != "" || d.Get("effective_namespace").(string) != "default" {
+		t.Errorf("namespace = %q, effective_namespace = %q, want only the effective namespace set to the provider namespace", d.Get("namespace"), d.Get("effective_namespace"))
+	}
+}
@@ -0,0 +1,184 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const defaultPodFileMaxSize = 1024 * 1024
+
+func dataSourceKubectlPodFileSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"kubeconfig":          kubeconfigOverrideSchema(),
+		"namespace":           namespaceSchema(),
+		"effective_namespace": effectiveNamespaceSchema(),
+		"pod": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"pod", "label_selector"},
+			Description:  "Name of the pod to read the file from.",
+		},
+		"label_selector": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Label selector of the pod to read the file from. Running pods are preferred when several match, then the newest.",
+		},
+		"container": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Container to read the file from, required when the pod has several.",
+		},
+		"path": &schema.Schema{
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validation.StringIsNotEmpty,
+			Description:  "Absolute path of the file in the container.",
+		},
+		"archive": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Read a tar archive of the path, which may be a directory, like kubectl cp. Requires tar in the container, while reading a file requires cat.",
+		},
+		"max_size": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      defaultPodFileMaxSize,
+			ValidateFunc: validation.IntAtLeast(1),
+			Description:  "Size in bytes above which the read fails, as the content is kept in the state.",
+		},
+		"pod_name": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"content": &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Sensitive:   true,
+			Description: "Content of the file, empty when it is not valid UTF-8 or an archive was read.",
+		},
+		"content_base64": &schema.Schema{
+			Type:      schema.TypeString,
+			Computed:  true,
+			Sensitive: true,
+		},
+		"sha256": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"size": &schema.Schema{
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+	}
+}
+
+func dataSourceKubectlPodFile() *schema.Resource {
+	dataSourceSchema := dataSourceKubectlPodFileSchema()
+	dataSourceSchema["timeouts"] = dataSourceTimeoutsSchema()
+
+	return &schema.Resource{
+		ReadContext: readWithDataSourceTimeout(dataSourceKubectlPodFileRead),
+		Schema:      dataSourceSchema,
+	}
+}
+
+func dataSourceKubectlPodFileRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := kubeProviderFromResourceData(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if skip, err := provider.skipReadForUnknownConfig(d); skip {
+		return diag.FromErr(err)
+	}
+	client, err := provider.MainClientset()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	namespace := namespaceFromResourceData(d, provider)
+	var pod *corev1.Pod
+	err = provider.withReadRetry(ctx, "select pod", func() (err error) {
+		pod, err = selectPod(ctx, client.CoreV1().RESTClient(), namespace, d.Get("pod").(string), d.Get("label_selector").(string))
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	filePath := d.Get("path").(string)
+	command := podFileCommand(filePath, d.Get("archive").(bool))
+	var content *limitedBuffer
+	var stderr bytes.Buffer
+	var exitCode int
+	err = provider.withReadRetry(ctx, "read pod file", func() (err error) {
+		content = &limitedBuffer{limit: d.Get("max_size").(int)}
+		stderr.Reset()
+		exitCode, err = provider.execInPod(ctx, client.CoreV1().RESTClient(), namespace, pod.Name, d.Get("container").(string), command, nil, content, &stderr)
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if exitCode != 0 {
+		return diag.Errorf("failed to read %s in pod %s/%s: %s", filePath, namespace, pod.Name, strings.TrimSpace(stderr.String()))
+	}
+	if content.exceeded {
+		return diag.Errorf("%s in pod %s/%s is larger than max_size of %d bytes", filePath, namespace, pod.Name, content.limit)
+	}
+
+	data := content.Bytes()
+	text := ""
+	if !d.Get("archive").(bool) && utf8.Valid(data) {
+		text = string(data)
+	}
+	checksum := fmt.Sprintf("%x", sha256.Sum256(data))
+	_ = d.Set("effective_namespace", namespace)
+	_ = d.Set("pod_name", pod.Name)
+	_ = d.Set("content", text)
+	_ = d.Set("content_base64", base64.StdEncoding.EncodeToString(data))
+	_ = d.Set("sha256", checksum)
+	_ = d.Set("size", len(data))
+
+	d.SetId(checksum)
+	return nil
+}
+
+// podFileCommand returns the command writing the file at filePath, or a tar archive
+// of it, to stdout. The archive holds the path relative to its parent, like kubectl cp.
+func podFileCommand(filePath string, archive bool) []string {
+	if !archive {
+		return []string{"cat", "--", filePath}
+	}
+	return []string{"tar", "cf", "-", "-C", path.Dir(filePath), path.Base(filePath)}
+}
+
+// limitedBuffer keeps up to limit bytes, discarding the rest while still consuming
+// it, so a remote command writing more is not blocked.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit    int
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.Len(); len(p) > remaining {
+		b.exceeded = true
+		if remaining > 0 {
+			b.Buffer.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,47 @@
+package kubernetes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_podFileCommand(t *testing.T) {
+	tests := []struct {
+		path    string
+		archive bool
+		then    []string
+	}{
+		{"/etc/rancher/k3s/k3s.yaml", false, []string{"cat", "--", "/etc/rancher/k3s/k3s.yaml"}},
+		{"/var/run/secrets/kubernetes.io/serviceaccount", true, []string{"tar", "cf", "-", "-C", "/var/run/secrets/kubernetes.io", "serviceaccount"}},
+	}
+	for _, tt := range tests {
+		if got := podFileCommand(tt.path, tt.archive); !reflect.DeepEqual(got, tt.then) {
+			t.Errorf("podFileCommand(%q, %v) = %v, want %v", tt.path, tt.archive, got, tt.then)
+		}
+	}
+}
+
+func Test_limitedBuffer(t *testing.T) {
+	tests := []struct {
+		name         string
+		given        []string
+		thenContent  string
+		thenExceeded bool
+	}{
+		{"validate content within the limit", []string{"ab", "cd"}, "abcd", false},
+		{"validate content above the limit", []string{"ab", "cdef", "gh"}, "abcd", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &limitedBuffer{limit: 4}
+			for _, p := range tt.given {
+				if n, err := b.Write([]byte(p)); n != len(p) || err != nil {
+					t.Fatalf("Write() = %d, %v, want the whole write consumed", n, err)
+				}
+			}
+			if b.String() != tt.thenContent || b.exceeded != tt.thenExceeded {
+				t.Errorf("limitedBuffer = %q exceeded %v, want %q exceeded %v", b.String(), b.exceeded, tt.thenContent, tt.thenExceeded)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func dataSourceKubectlPodLogsSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"kubeconfig":          kubeconfigOverrideSchema(),
+		"namespace":           namespaceSchema(),
+		"effective_namespace": effectiveNamespaceSchema(),
+		"pod": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			ExactlyOneOf: []string{"pod", "label_selector"},
+			Description:  "Name of the pod to read the logs of.",
+		},
+		"label_selector": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Label selector of the pod to read the logs of, e.g. job-name=migrate. Running pods are preferred when several match, then the newest.",
+		},
+		"container": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Container to read the logs of, required when the pod has several.",
+		},
+		"previous": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Read the logs of the previous instance of the container.",
+		},
+		"tail_lines": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ValidateFunc: validation.IntAtLeast(1),
+			Description:  "Number of lines from the end of the logs to read.",
+		},
+		"limit_bytes": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ValidateFunc: validation.IntAtLeast(1),
+			Description:  "Number of bytes of the logs to read at most.",
+		},
+		"since": &schema.Schema{
+			Type:          schema.TypeString,
+			Optional:      true,
+			ConflictsWith: []string{"since_time"},
+			ValidateFunc:  validateDurationAtLeast(time.Second),
+			Description:   "Only read the logs newer than the duration, e.g. 1h, of at least 1s.",
+		},
+		"since_time": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.IsRFC3339Time,
+			Description:  "Only read the logs after the RFC3339 time.",
+		},
+		"pod_name": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"logs": &schema.Schema{
+			Type:      schema.TypeString,
+			Computed:  true,
+			Sensitive: true,
+		},
+	}
+}
+
+func dataSourceKubectlPodLogs() *schema.Resource {
+	dataSourceSchema := dataSourceKubectlPodLogsSchema()
+	dataSourceSchema["timeouts"] = dataSourceTimeoutsSchema()
+
+	return &schema.Resource{
+		ReadContext: readWithDataSourceTimeout(dataSourceKubectlPodLogsRead),
+		Schema:      dataSourceSchema,
+	}
+}
+
+func dataSourceKubectlPodLogsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := kubeProviderFromResourceData(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if skip, err := provider.skipReadForUnknownConfig(d); skip {
+		return diag.FromErr(err)
+	}
+	client, err := provider.MainClientset()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	namespace := namespaceFromResourceData(d, provider)
+	var pod *corev1.Pod
+	err = provider.withReadRetry(ctx, "select pod", func() (err error) {
+		pod, err = selectPod(ctx, client.CoreV1().RESTClient(), namespace, d.Get("pod").(string), d.Get("label_selector").(string))
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	options := expandPodLogOptions(d)
+	var logs []byte
+	err = provider.withReadRetry(ctx, "read pod logs", func() (err error) {
+		logs, err = client.CoreV1().Pods(namespace).GetLogs(pod.Name, options).Context(ctx).Do().Raw()
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(fmt.Errorf("failed to read the logs of pod %s/%s: %w", namespace, pod.Name, err))
+	}
+
+	_ = d.Set("effective_namespace", namespace)
+	_ = d.Set("pod_name", pod.Name)
+	_ = d.Set("logs", string(logs))
+
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256(logs)))
+	return nil
+}
+
+func expandPodLogOptions(d *schema.ResourceData) *corev1.PodLogOptions {
+	options := &corev1.PodLogOptions{
+		Container: d.Get("container").(string),
+		Previous:  d.Get("previous").(bool),
+	}
+	if v, ok := d.GetOk("tail_lines"); ok {
+		tailLines := int64(v.(int))
+		options.TailLines = &tailLines
+	}
+	if v, ok := d.GetOk("limit_bytes"); ok {
+		limitBytes := int64(v.(int))
+		options.LimitBytes = &limitBytes
+	}
+	// validated by the schema
+	if v, ok := d.GetOk("since"); ok {
+		since, _ := time.ParseDuration(v.(string))
+		sinceSeconds := int64(since.Seconds())
+		options.SinceSeconds = &sinceSeconds
+	}
+	if v, ok := d.GetOk("since_time"); ok {
+		sinceTime, _ := time.Parse(time.RFC3339, v.(string))
+		options.SinceTime = &v1.Time{Time: sinceTime}
+	}
+	return options
+}
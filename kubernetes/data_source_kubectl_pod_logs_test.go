@@ -0,0 +1,86 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	restclient "k8s.io/client-go/rest"
+)
+
+func Test_dataSourceKubectlPodLogsRead(t *testing.T) {
+	var requested url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/jobs/pods":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(&corev1.PodList{Items: []corev1.Pod{{
+				ObjectMeta: v1.ObjectMeta{Name: "migrate-x7k2p", Namespace: "jobs"},
+				Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+			}}})
+		case "/api/v1/namespaces/jobs/pods/migrate-x7k2p/log":
+			requested = r.URL.Query()
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("password: generated\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := newKubeProvider(&restclient.Config{Host: server.URL})
+	provider.namespace = "jobs"
+
+	tests := []struct {
+		name  string
+		given map[string]interface{}
+		then  url.Values
+	}{
+		{
+			"validate defaults",
+			map[string]interface{}{},
+			url.Values{},
+		},
+		{
+			"validate container, previous and limits",
+			map[string]interface{}{"container": "migrate", "previous": true, "tail_lines": 10, "limit_bytes": 4096},
+			url.Values{"container": {"migrate"}, "previous": {"true"}, "tailLines": {"10"}, "limitBytes": {"4096"}},
+		},
+		{
+			"validate since",
+			map[string]interface{}{"since": "1h"},
+			url.Values{"sinceSeconds": {"3600"}},
+		},
+		{
+			"validate since_time",
+			map[string]interface{}{"since_time": "2026-10-19T12:00:00Z"},
+			url.Values{"sinceTime": {"2026-10-19T12:00:00Z"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]interface{}{"label_selector": "job-name=migrate"}
+			for k, v := range tt.given {
+				config[k] = v
+			}
+			d := schema.TestResourceDataRaw(t, dataSourceKubectlPodLogs().Schema, config)
+			if diags := dataSourceKubectlPodLogsRead(context.Background(), d, provider); diags.HasError() {
+				t.Fatalf("dataSourceKubectlPodLogsRead() = %v", diags)
+			}
+
+			if !reflect.DeepEqual(requested, tt.then) {
+				t.Errorf("requested the logs with %v, want %v", requested, tt.then)
+			}
+			if d.Get("logs").(string) != "password: generated\n" || d.Get("pod_name").(string) != "migrate-x7k2p" || d.Get("effective_namespace").(string) != "jobs" {
+				t.Errorf("logs = %q of pod %q in %q, want the logs of the selected pod", d.Get("logs"), d.Get("pod_name"), d.Get("effective_namespace"))
+			}
+		})
+	}
+}
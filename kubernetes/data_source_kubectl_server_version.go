@@ -1,77 +1,175 @@
 package kubernetes
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
+	"strconv"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	"strings"
+	k8sversion "k8s.io/apimachinery/pkg/version"
 )
 
-func dataSourceKubectlServerVersion() *schema.Resource {
-	return &schema.Resource{
-		Read: dataSourceKubectlServerVersionRead,
-		Schema: map[string]*schema.Schema{
-			"version": &schema.Schema{
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-			"major": &schema.Schema{
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-			"minor": &schema.Schema{
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-			"patch": &schema.Schema{
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-			"git_version": &schema.Schema{
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-			"git_commit": &schema.Schema{
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-			"build_date": &schema.Schema{
-				Type:     schema.TypeString,
-				Computed: true,
-			},
-			"platform": &schema.Schema{
-				Type:     schema.TypeString,
-				Computed: true,
+func dataSourceKubectlServerVersionSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"constraint": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Version constraint the server version is checked against, e.g. \">= 1.21, < 1.28\".",
+			ValidateFunc: func(v interface{}, k string) (ws []string, es []error) {
+				if _, err := goversion.NewConstraint(v.(string)); err != nil {
+					es = append(es, fmt.Errorf("%q: %s", k, err))
+				}
+				return
 			},
 		},
+		"fail_if_unsatisfied": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Fail the read when the server version does not satisfy the constraint.",
+		},
+		"kubeconfig": kubeconfigOverrideSchema(),
+		"satisfies": &schema.Schema{
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"version": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"major": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"minor": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"patch": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"major_number": &schema.Schema{
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"minor_number": &schema.Schema{
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"patch_number": &schema.Schema{
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"prerelease": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"build_metadata": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"distribution": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"supported_apis": &schema.Schema{
+			Type:        schema.TypeMap,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeBool},
+			Description: "Whether commonly branched APIs (e.g. batch/v1/CronJob) are served, based on discovery.",
+		},
+		"git_version": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"git_commit": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"build_date": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"platform": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+	}
+}
+
+func dataSourceKubectlServerVersion() *schema.Resource {
+	dataSourceSchema := dataSourceKubectlServerVersionSchema()
+	dataSourceSchema["timeouts"] = dataSourceTimeoutsSchema()
+
+	return &schema.Resource{
+		ReadContext: readWithDataSourceTimeout(dataSourceKubectlServerVersionRead),
+		Schema:      dataSourceSchema,
 	}
 }
 
-func dataSourceKubectlServerVersionRead(d *schema.ResourceData, meta interface{}) error {
-	provider := meta.(*KubeProvider)
+func dataSourceKubectlServerVersionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := kubeProviderFromResourceData(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if skip, err := provider.skipReadForUnknownConfig(d); skip {
+		return diag.FromErr(err)
+	}
 	discoveryClient, err := provider.ToDiscoveryClient()
 	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var serverVersion *k8sversion.Info
+	err = provider.withReadRetry(ctx, "get server version", func() (err error) {
+		serverVersion, err = serverVersionWithContext(ctx, discoveryClient)
 		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
 	}
 
-	discoveryClient.Invalidate()
-	serverVersion, err := discoveryClient.ServerVersion()
+	parsedVersion, err := parseServerVersion(serverVersion)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	_ = d.Set("major", strconv.Itoa(parsedVersion.Major))
+	_ = d.Set("minor", strconv.Itoa(parsedVersion.Minor))
+	_ = d.Set("patch", strconv.Itoa(parsedVersion.Patch))
+	_ = d.Set("major_number", parsedVersion.Major)
+	_ = d.Set("minor_number", parsedVersion.Minor)
+	_ = d.Set("patch_number", parsedVersion.Patch)
+	_ = d.Set("prerelease", parsedVersion.Prerelease)
+	_ = d.Set("build_metadata", parsedVersion.BuildMetadata)
+	_ = d.Set("distribution", parsedVersion.Distribution)
+
+	satisfies := true
+	if v, ok := d.GetOk("constraint"); ok {
+		satisfies, err = parsedVersion.Satisfies(v.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if !satisfies && d.Get("fail_if_unsatisfied").(bool) {
+			return diag.Errorf("server version %s does not satisfy the constraint %q", serverVersion.GitVersion, v.(string))
+		}
 	}
+	_ = d.Set("satisfies", satisfies)
 
-	serverSemver := strings.Split(serverVersion.String(), ".")
-	if len(serverSemver) >= 3 {
-		_ = d.Set("major", strings.ReplaceAll(serverSemver[0], "v", ""))
-		_ = d.Set("minor", serverSemver[1])
-		_ = d.Set("patch", strings.Split(serverSemver[2], "-")[0])
-	} else {
-		_ = d.Set("major", serverVersion.Major)
-		_ = d.Set("minor", serverVersion.Minor)
-		_ = d.Set("patch", "")
+	var supportedAPIs map[string]bool
+	err = provider.withReadRetry(ctx, "discover supported APIs", func() (err error) {
+		supportedAPIs, err = discoverSupportedAPIs(ctx, discoveryClient)
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
 	}
+	_ = d.Set("supported_apis", supportedAPIs)
 
-	_ = d.Set("version", strings.Split(serverVersion.String(), "-")[0])
+	_ = d.Set("version", parsedVersion.String())
 	_ = d.Set("git_version", serverVersion.GitVersion)
 	_ = d.Set("git_commit", serverVersion.GitCommit)
 	_ = d.Set("build_date", serverVersion.BuildDate)
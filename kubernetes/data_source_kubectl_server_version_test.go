@@ -0,0 +1,78 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	restclient "k8s.io/client-go/rest"
+)
+
+func Test_dataSourceKubectlServerVersionRead_discoveryCacheTTL(t *testing.T) {
+	var lock sync.Mutex
+	discovered := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/version" {
+			_, _ = w.Write([]byte(`{"major":"1","minor":"27","gitVersion":"v1.27.4"}`))
+			return
+		}
+		lock.Lock()
+		discovered++
+		lock.Unlock()
+		groupVersion := strings.TrimPrefix(r.URL.Path, "/apis/")
+		resources := metav1.APIResourceList{GroupVersion: groupVersion}
+		for _, api := range commonlyBranchedAPIs {
+			if api.groupVersion == groupVersion {
+				resources.APIResources = append(resources.APIResources, metav1.APIResource{Kind: api.kind})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(resources)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "discovery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name           string
+		ttl            time.Duration
+		thenDiscovered int
+	}{
+		{"validate the first read fills the cache", 10 * time.Minute, len(commonlyBranchedAPIs)},
+		{"validate the cache is used within the ttl", 10 * time.Minute, len(commonlyBranchedAPIs)},
+		{"validate the cache is refreshed after the ttl", time.Nanosecond, 2 * len(commonlyBranchedAPIs)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// each read runs with a new provider, as separate Terraform runs do
+			provider := newKubeProvider(&restclient.Config{Host: server.URL})
+			provider.discoveryCache = discoveryCacheDisk
+			provider.discoveryCacheDir = dir
+			provider.discoveryCacheTTL = tt.ttl
+			d := schema.TestResourceDataRaw(t, dataSourceKubectlServerVersion().Schema, map[string]interface{}{})
+			if diags := dataSourceKubectlServerVersionRead(context.Background(), d, provider); diags.HasError() {
+				t.Fatalf("dataSourceKubectlServerVersionRead() = %v", diags)
+			}
+
+			if !d.Get("supported_apis.batch/v1/CronJob").(bool) {
+				t.Errorf("supported_apis = %v, want the served APIs", d.Get("supported_apis"))
+			}
+			if discovered != tt.thenDiscovered {
+				t.Errorf("discovered %d group versions, want %d", discovered, tt.thenDiscovered)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	restclient "k8s.io/client-go/rest"
+)
+
+// The sources of the identity, from the most to the least authoritative.
+const (
+	whoamiSourceSelfSubjectReview = "SelfSubjectReview"
+	whoamiSourceTokenReview       = "TokenReview"
+	whoamiSourceToken             = "token"
+	whoamiSourceClientCertificate = "client_certificate"
+)
+
+// selfSubjectReviewVersions are the versions of the SelfSubjectReview API, served
+// from Kubernetes 1.26 (v1alpha1), 1.27 (v1beta1) and 1.28 (v1).
+var selfSubjectReviewVersions = []string{"v1", "v1beta1", "v1alpha1"}
+
+// selfSubjectReview is the SelfSubjectReview API, which client-go v0.17 predates.
+type selfSubjectReview struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Status     struct {
+		UserInfo authenticationv1.UserInfo `json:"userInfo"`
+	} `json:"status"`
+}
+
+func dataSourceKubectlWhoamiSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"kubeconfig": kubeconfigOverrideSchema(),
+		"username": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"uid": &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"groups": &schema.Schema{
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"extra": &schema.Schema{
+			Type:        schema.TypeMap,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Additional information provided by the authenticator, multiple values being comma separated.",
+		},
+		"source": &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Where the identity comes from: SelfSubjectReview or TokenReview as authenticated by the server, or token or client_certificate when parsed from the credentials without verification.",
+		},
+	}
+}
+
+func dataSourceKubectlWhoami() *schema.Resource {
+	dataSourceSchema := dataSourceKubectlWhoamiSchema()
+	dataSourceSchema["timeouts"] = dataSourceTimeoutsSchema()
+
+	return &schema.Resource{
+		ReadContext: readWithDataSourceTimeout(dataSourceKubectlWhoamiRead),
+		Schema:      dataSourceSchema,
+	}
+}
+
+func dataSourceKubectlWhoamiRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := kubeProviderFromResourceData(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if skip, err := provider.skipReadForUnknownConfig(d); skip {
+		return diag.FromErr(err)
+	}
+	client, err := provider.MainClientset()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var userInfo *authenticationv1.UserInfo
+	var source string
+	err = provider.withReadRetry(ctx, "review the identity", func() (err error) {
+		userInfo, source, err = provider.whoami(ctx, client.AuthenticationV1().RESTClient())
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	extra := map[string]string{}
+	for k, v := range userInfo.Extra {
+		extra[k] = strings.Join(v, ",")
+	}
+	_ = d.Set("username", userInfo.Username)
+	_ = d.Set("uid", userInfo.UID)
+	_ = d.Set("groups", userInfo.Groups)
+	_ = d.Set("extra", extra)
+	_ = d.Set("source", source)
+
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256([]byte(userInfo.String()))))
+	return nil
+}
+
+// whoami returns the identity the credentials of the provider authenticate as,
+// along with its source. The SelfSubjectReview API is preferred, falling back to a
+// TokenReview of the bearer token and then to the claims of the token or the subject
+// of the client certificate on servers which predate it.
+func (p *KubeProvider) whoami(ctx context.Context, client restclient.Interface) (*authenticationv1.UserInfo, string, error) {
+	for _, version := range selfSubjectReviewVersions {
+		body, err := json.Marshal(&selfSubjectReview{
+			APIVersion: "authentication.k8s.io/" + version,
+			Kind:       "SelfSubjectReview",
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		result, err := client.Post().
+			AbsPath("/apis/authentication.k8s.io", version, "selfsubjectreviews").
+			Body(body).
+			Context(ctx).
+			Do().
+			Raw()
+		if apierrors.IsNotFound(err) {
+			log.Printf("[DEBUG] SelfSubjectReview %s is not served", version)
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		review := &selfSubjectReview{}
+		if err := json.Unmarshal(result, review); err != nil {
+			return nil, "", fmt.Errorf("unable to parse the SelfSubjectReview: %v", err)
+		}
+		return &review.Status.UserInfo, whoamiSourceSelfSubjectReview, nil
+	}
+
+	token, err := p.bearerToken()
+	if err != nil {
+		return nil, "", err
+	}
+	if token != "" {
+		review := &authenticationv1.TokenReview{
+			Spec: authenticationv1.TokenReviewSpec{Token: token},
+		}
+		err := createWithContext(ctx, client, "tokenreviews", review, review)
+		switch {
+		case err == nil && review.Status.Authenticated:
+			return &review.Status.User, whoamiSourceTokenReview, nil
+		case err == nil:
+			return nil, "", fmt.Errorf("the token of the provider is not authenticated: %s", review.Status.Error)
+		case apierrors.IsForbidden(err):
+			log.Printf("[DEBUG] Unable to review the token, parsing it instead: %s", err)
+		default:
+			return nil, "", err
+		}
+
+		userInfo, err := userInfoFromToken(token)
+		if err != nil {
+			return nil, "", err
+		}
+		return userInfo, whoamiSourceToken, nil
+	}
+
+	certificate, err := p.clientCertificate()
+	if err != nil {
+		return nil, "", err
+	}
+	if certificate != nil {
+		return userInfoFromCertificate(certificate), whoamiSourceClientCertificate, nil
+	}
+	return nil, "", fmt.Errorf("unable to determine the identity: the server does not serve SelfSubjectReview and the provider has no bearer token or client certificate")
+}
+
+// bearerToken returns the static bearer token of the provider, if any.
+func (p *KubeProvider) bearerToken() (string, error) {
+	if p.RestConfig.BearerToken != "" || p.RestConfig.BearerTokenFile == "" {
+		return p.RestConfig.BearerToken, nil
+	}
+	token, err := ioutil.ReadFile(p.RestConfig.BearerTokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// clientCertificate returns the client certificate of the provider, if any.
+func (p *KubeProvider) clientCertificate() (*x509.Certificate, error) {
+	data := p.RestConfig.CertData
+	if len(data) == 0 && p.RestConfig.CertFile != "" {
+		var err error
+		if data, err = ioutil.ReadFile(p.RestConfig.CertFile); err != nil {
+			return nil, err
+		}
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse the client certificate: no PEM data found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// userInfoFromToken returns the identity claimed by the JWT, without verifying it.
+// Service account tokens map to the user and groups of the service account, like
+// the server does; other tokens, e.g. OIDC ID tokens, to their sub and groups claims.
+func userInfoFromToken(token string) (*authenticationv1.UserInfo, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("unable to determine the identity: the token of the provider is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the token: %s", err)
+	}
+	var claims struct {
+		Subject          string   `json:"sub"`
+		Groups           []string `json:"groups"`
+		LegacyNamespace  string   `json:"kubernetes.io/serviceaccount/namespace"`
+		LegacyName       string   `json:"kubernetes.io/serviceaccount/service-account.name"`
+		LegacyUID        string   `json:"kubernetes.io/serviceaccount/service-account.uid"`
+		ServiceAccountIO *struct {
+			Namespace      string `json:"namespace"`
+			ServiceAccount struct {
+				Name string `json:"name"`
+				UID  string `json:"uid"`
+			} `json:"serviceaccount"`
+		} `json:"kubernetes.io"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("failed to decode the token: %s", err)
+	}
+
+	namespace, name, uid := claims.LegacyNamespace, claims.LegacyName, claims.LegacyUID
+	if claims.ServiceAccountIO != nil {
+		namespace, name, uid = claims.ServiceAccountIO.Namespace, claims.ServiceAccountIO.ServiceAccount.Name, claims.ServiceAccountIO.ServiceAccount.UID
+	}
+	if namespace != "" && name != "" {
+		return &authenticationv1.UserInfo{
+			Username: fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name),
+			UID:      uid,
+			Groups:   []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"},
+		}, nil
+	}
+	return &authenticationv1.UserInfo{
+		Username: claims.Subject,
+		Groups:   append(claims.Groups, "system:authenticated"),
+	}, nil
+}
+
+// userInfoFromCertificate returns the identity of the client certificate, the
+// common name being the user and the organizations the groups.
+func userInfoFromCertificate(certificate *x509.Certificate) *authenticationv1.UserInfo {
+	return &authenticationv1.UserInfo{
+		Username: certificate.Subject.CommonName,
+		Groups:   append(append([]string{}, certificate.Subject.Organization...), "system:authenticated"),
+	}
+}
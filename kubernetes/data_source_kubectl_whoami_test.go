@@ -0,0 +1,154 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+func testJWT(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"RS256"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+func Test_userInfoFromToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+		then  *authenticationv1.UserInfo
+	}{
+		{
+			"validate legacy service account token",
+			testJWT(`{"iss":"kubernetes/serviceaccount","kubernetes.io/serviceaccount/namespace":"ci","kubernetes.io/serviceaccount/service-account.name":"deployer","kubernetes.io/serviceaccount/service-account.uid":"1234"}`),
+			&authenticationv1.UserInfo{Username: "system:serviceaccount:ci:deployer", UID: "1234", Groups: []string{"system:serviceaccounts", "system:serviceaccounts:ci", "system:authenticated"}},
+		},
+		{
+			"validate bound service account token",
+			testJWT(`{"sub":"system:serviceaccount:ci:deployer","kubernetes.io":{"namespace":"ci","serviceaccount":{"name":"deployer","uid":"1234"}}}`),
+			&authenticationv1.UserInfo{Username: "system:serviceaccount:ci:deployer", UID: "1234", Groups: []string{"system:serviceaccounts", "system:serviceaccounts:ci", "system:authenticated"}},
+		},
+		{
+			"validate OIDC token",
+			testJWT(`{"sub":"jane","groups":["admins"]}`),
+			&authenticationv1.UserInfo{Username: "jane", Groups: []string{"admins", "system:authenticated"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userInfo, err := userInfoFromToken(tt.given)
+			if err != nil {
+				t.Fatalf("userInfoFromToken() error = %v", err)
+			}
+			if !reflect.DeepEqual(userInfo, tt.then) {
+				t.Errorf("userInfoFromToken() = %+v, want %+v", userInfo, tt.then)
+			}
+		})
+	}
+
+	if _, err := userInfoFromToken("opaque-token"); err == nil {
+		t.Error("userInfoFromToken() of a token which is not a JWT did not fail")
+	}
+}
+
+func TestKubeProvider_whoami(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kubernetes-admin", Organization: []string{"system:masters"}},
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	tests := []struct {
+		name       string
+		served     map[string]string
+		given      restclient.Config
+		thenSource string
+		thenUser   string
+	}{
+		{
+			"validate SelfSubjectReview falls back to older versions",
+			map[string]string{
+				"/apis/authentication.k8s.io/v1beta1/selfsubjectreviews": `{"status":{"userInfo":{"username":"jane"}}}`,
+			},
+			restclient.Config{},
+			whoamiSourceSelfSubjectReview,
+			"jane",
+		},
+		{
+			"validate TokenReview without SelfSubjectReview",
+			map[string]string{
+				"/apis/authentication.k8s.io/v1/tokenreviews": `{"status":{"authenticated":true,"user":{"username":"ci"}}}`,
+			},
+			restclient.Config{BearerToken: "opaque-token"},
+			whoamiSourceTokenReview,
+			"ci",
+		},
+		{
+			"validate token claims without permission to review tokens",
+			map[string]string{},
+			restclient.Config{BearerToken: testJWT(`{"sub":"jane"}`)},
+			whoamiSourceToken,
+			"jane",
+		},
+		{
+			"validate client certificate without token",
+			map[string]string{},
+			restclient.Config{TLSClientConfig: restclient.TLSClientConfig{CertData: certificate}},
+			whoamiSourceClientCertificate,
+			"kubernetes-admin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if body, ok := tt.served[r.URL.Path]; ok {
+					_, _ = w.Write([]byte(body))
+				} else if r.URL.Path == "/apis/authentication.k8s.io/v1/tokenreviews" {
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403}`))
+				} else {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+				}
+			}))
+			defer server.Close()
+
+			client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			provider := &KubeProvider{RestConfig: tt.given}
+			userInfo, source, err := provider.whoami(context.Background(), client.AuthenticationV1().RESTClient())
+			if err != nil {
+				t.Fatalf("whoami() error = %v", err)
+			}
+			if source != tt.thenSource || userInfo.Username != tt.thenUser {
+				t.Errorf("whoami() = %q from %s, want %q from %s", userInfo.Username, source, tt.thenUser, tt.thenSource)
+			}
+		})
+	}
+}
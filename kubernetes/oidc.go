@@ -0,0 +1,47 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"log"
+	"strings"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// expandOIDCAuthProvider builds the configuration of the client-go oidc auth
+// provider, which refreshes the ID token with the refresh token once it expires.
+func expandOIDCAuthProvider(spec map[string]interface{}) *clientcmdapi.AuthProviderConfig {
+	config := map[string]string{
+		"idp-issuer-url": spec["issuer_url"].(string),
+		"client-id":      spec["client_id"].(string),
+	}
+	if v := spec["client_secret"].(string); v != "" {
+		config["client-secret"] = v
+	}
+	if v := spec["refresh_token"].(string); v != "" {
+		config["refresh-token"] = v
+	}
+	if v := spec["id_token"].(string); v != "" {
+		config["id-token"] = v
+	}
+	if v := spec["certificate_authority"].(string); v != "" {
+		config["idp-certificate-authority-data"] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+	if v := expandStringSlice(spec["extra_scopes"].([]interface{})); len(v) > 0 {
+		config["extra-scopes"] = strings.Join(v, ",")
+	}
+
+	return &clientcmdapi.AuthProviderConfig{
+		Name:   "oidc",
+		Config: config,
+	}
+}
+
+// memoryAuthConfigPersister accepts the tokens refreshed by an auth provider, which
+// keeps them in memory itself, as there is no kubeconfig file to write them back to.
+type memoryAuthConfigPersister struct{}
+
+func (p *memoryAuthConfigPersister) Persist(map[string]string) error {
+	log.Printf("[DEBUG] Refreshed auth provider tokens")
+	return nil
+}
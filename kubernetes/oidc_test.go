@@ -0,0 +1,59 @@
+package kubernetes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_expandOIDCAuthProvider(t *testing.T) {
+	tests := []struct {
+		name  string
+		given map[string]interface{}
+		then  map[string]string
+	}{
+		{
+			"validate required settings",
+			map[string]interface{}{"issuer_url": "https://dex.example.com", "client_id": "terraform"},
+			map[string]string{"idp-issuer-url": "https://dex.example.com", "client-id": "terraform"},
+		},
+		{
+			"validate all settings",
+			map[string]interface{}{
+				"issuer_url":            "https://dex.example.com",
+				"client_id":             "terraform",
+				"client_secret":         "secret",
+				"refresh_token":         "refresh",
+				"id_token":              "id",
+				"certificate_authority": "-----BEGIN CERTIFICATE-----",
+				"extra_scopes":          []interface{}{"groups", "email"},
+			},
+			map[string]string{
+				"idp-issuer-url":                 "https://dex.example.com",
+				"client-id":                      "terraform",
+				"client-secret":                  "secret",
+				"refresh-token":                  "refresh",
+				"id-token":                       "id",
+				"idp-certificate-authority-data": "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0t",
+				"extra-scopes":                   "groups,email",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := map[string]interface{}{
+				"client_secret":         "",
+				"refresh_token":         "",
+				"id_token":              "",
+				"certificate_authority": "",
+				"extra_scopes":          []interface{}{},
+			}
+			for k, v := range tt.given {
+				spec[k] = v
+			}
+			config := expandOIDCAuthProvider(spec)
+			if config.Name != "oidc" || !reflect.DeepEqual(config.Config, tt.then) {
+				t.Errorf("expandOIDCAuthProvider() = %s %v, want oidc %v", config.Name, config.Config, tt.then)
+			}
+		})
+	}
+}
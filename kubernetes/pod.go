@@ -0,0 +1,115 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// selectPod returns the pod of the given name, or the pod matching the label selector.
+// When several pods match, running pods win over the others and the newest pod over
+// older ones, so e.g. the pod of the last run of a job is selected.
+func selectPod(ctx context.Context, client restclient.Interface, namespace, name, labelSelector string) (*corev1.Pod, error) {
+	if name != "" {
+		pod := &corev1.Pod{}
+		err := client.Get().
+			Namespace(namespace).
+			Resource("pods").
+			Name(name).
+			Context(ctx).
+			Do().
+			Into(pod)
+		return pod, err
+	}
+
+	pods := &corev1.PodList{}
+	if err := listWithContext(ctx, client, namespace, "pods", v1.ListOptions{LabelSelector: labelSelector}, pods); err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pod in namespace %q matches the label selector %q", namespace, labelSelector)
+	}
+	sort.SliceStable(pods.Items, func(i, j int) bool {
+		iRunning, jRunning := pods.Items[i].Status.Phase == corev1.PodRunning, pods.Items[j].Status.Phase == corev1.PodRunning
+		if iRunning != jRunning {
+			return iRunning
+		}
+		return pods.Items[j].CreationTimestamp.Before(&pods.Items[i].CreationTimestamp)
+	})
+	return &pods.Items[0], nil
+}
+
+// newSPDYExecutor is a variable so tests can run commands without a cluster.
+var newSPDYExecutor = remotecommand.NewSPDYExecutor
+
+// checkStreamingProxy fails the operation when proxy_url is set. Exec and port-forward
+// connect through the SPDY transport of client-go v0.17, which can't be wrapped with
+// the proxy and only honours the HTTP_PROXY and HTTPS_PROXY environment variables,
+// so the connection would otherwise silently bypass the proxy.
+func (p *KubeProvider) checkStreamingProxy(operation string) error {
+	if p.proxyURL == "" {
+		return nil
+	}
+	return fmt.Errorf("%s is not supported with proxy_url %q: the SPDY connection it needs can't be proxied by the provider, "+
+		"unset proxy_url and set the HTTPS_PROXY environment variable instead (SOCKS5 proxies are not supported)", operation, p.proxyURL)
+}
+
+// execInPod runs the command in the container of the pod through the exec
+// subresource, streaming stdin to it and its output to stdout and stderr. A command
+// exiting with a non-zero code is not an error, the code being returned instead.
+func (p *KubeProvider) execInPod(ctx context.Context, client restclient.Interface, namespace, pod, container string, command []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	if err := p.checkStreamingProxy(fmt.Sprintf("running %q in pod %s/%s", strings.Join(command, " "), namespace, pod)); err != nil {
+		return 0, err
+	}
+	request := client.Post().
+		Namespace(namespace).
+		Resource("pods").
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	config := p.RestConfig
+	executor, err := newSPDYExecutor(&config, "POST", request.URL())
+	if err != nil {
+		return 0, err
+	}
+
+	// The executor of client-go v0.17 takes no context, so a cancelled command is left
+	// to finish in the pod, its streams being closed with the connection.
+	streamed := make(chan error, 1)
+	go func() {
+		streamed <- executor.Stream(remotecommand.StreamOptions{
+			Stdin:  stdin,
+			Stdout: stdout,
+			Stderr: stderr,
+		})
+	}()
+	select {
+	case err = <-streamed:
+	case <-ctx.Done():
+		return 0, fmt.Errorf("failed to run %q in pod %s/%s: %w", strings.Join(command, " "), namespace, pod, ctx.Err())
+	}
+
+	if exitErr, ok := err.(utilexec.ExitError); ok && exitErr.Exited() {
+		return exitErr.ExitStatus(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to run %q in pod %s/%s: %w", strings.Join(command, " "), namespace, pod, err)
+	}
+	return 0, nil
+}
@@ -0,0 +1,142 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+func Test_selectPod(t *testing.T) {
+	now := time.Now()
+	pod := func(name string, phase corev1.PodPhase, age time.Duration) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: name, CreationTimestamp: v1.NewTime(now.Add(-age))},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		given []corev1.Pod
+		then  string
+	}{
+		{
+			"validate the newest pod wins",
+			[]corev1.Pod{pod("migrate-1", corev1.PodSucceeded, 2*time.Hour), pod("migrate-2", corev1.PodFailed, time.Hour)},
+			"migrate-2",
+		},
+		{
+			"validate running pods win",
+			[]corev1.Pod{pod("web-1", corev1.PodRunning, 2*time.Hour), pod("web-2", corev1.PodPending, time.Minute)},
+			"web-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var selector string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				selector = r.URL.Query().Get("labelSelector")
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(&corev1.PodList{Items: tt.given})
+			}))
+			defer server.Close()
+
+			client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			selected, err := selectPod(context.Background(), client.CoreV1().RESTClient(), "default", "", "app=web")
+			if err != nil {
+				t.Fatalf("selectPod() error = %v", err)
+			}
+			if selected.Name != tt.then || selector != "app=web" {
+				t.Errorf("selectPod() = %s selected with %q, want %s", selected.Name, selector, tt.then)
+			}
+		})
+	}
+}
+
+type fakeExecutor struct {
+	stdout string
+	err    error
+}
+
+func (e *fakeExecutor) Stream(options remotecommand.StreamOptions) error {
+	_, _ = options.Stdout.Write([]byte(e.stdout))
+	return e.err
+}
+
+func TestKubeProvider_execInPod(t *testing.T) {
+	original := newSPDYExecutor
+	defer func() { newSPDYExecutor = original }()
+
+	tests := []struct {
+		name         string
+		given        error
+		thenExitCode int
+		thenError    bool
+	}{
+		{"validate success", nil, 0, false},
+		{"validate the exit code of the command", utilexec.CodeExitError{Err: errors.New("command terminated with exit code 3"), Code: 3}, 3, false},
+		{"validate stream failures", errors.New("connection reset"), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested *url.URL
+			newSPDYExecutor = func(config *restclient.Config, method string, url *url.URL) (remotecommand.Executor, error) {
+				requested = url
+				return &fakeExecutor{stdout: "token", err: tt.given}, nil
+			}
+
+			client, err := kubernetes.NewForConfig(&restclient.Config{Host: "https://example.com"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			provider := &KubeProvider{}
+			var stdout bytes.Buffer
+			exitCode, err := provider.execInPod(context.Background(), client.CoreV1().RESTClient(), "vault", "vault-0", "vault", []string{"cat", "/vault/token"}, nil, &stdout, &bytes.Buffer{})
+			if (err != nil) != tt.thenError || exitCode != tt.thenExitCode {
+				t.Errorf("execInPod() = %d, %v, want %d and error %v", exitCode, err, tt.thenExitCode, tt.thenError)
+			}
+			if stdout.String() != "token" {
+				t.Errorf("execInPod() stdout = %q, want the output of the command", stdout.String())
+			}
+			if requested.Path != "/api/v1/namespaces/vault/pods/vault-0/exec" || requested.Query()["command"][1] != "/vault/token" {
+				t.Errorf("execInPod() requested %s, want the exec subresource of the pod", requested)
+			}
+		})
+	}
+}
+
+func TestKubeProvider_execInPod_proxy(t *testing.T) {
+	original := newSPDYExecutor
+	defer func() { newSPDYExecutor = original }()
+	newSPDYExecutor = func(config *restclient.Config, method string, url *url.URL) (remotecommand.Executor, error) {
+		t.Errorf("execInPod() connected to %s, want it to fail before bypassing proxy_url", url)
+		return &fakeExecutor{}, nil
+	}
+
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &KubeProvider{proxyURL: "socks5://localhost:1080"}
+	_, err = provider.execInPod(context.Background(), client.CoreV1().RESTClient(), "vault", "vault-0", "vault", []string{"cat", "/vault/token"}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "proxy_url") {
+		t.Errorf("execInPod() error = %v, want an error naming proxy_url", err)
+	}
+}
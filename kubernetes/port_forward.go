@@ -0,0 +1,234 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// portForwards are the port-forwards of the provider process by resource id. They
+// end with the process, which Terraform starts for each run.
+var (
+	portForwards     = map[string]*portForward{}
+	portForwardsLock sync.Mutex
+)
+
+type portForward struct {
+	localHost string
+	localPort int
+	podName   string
+	stop      context.CancelFunc
+}
+
+// portForwardTarget is the pod, service or pod matching the label selector to forward to.
+type portForwardTarget struct {
+	namespace     string
+	pod           string
+	service       string
+	labelSelector string
+	remotePort    string
+}
+
+func (t portForwardTarget) String() string {
+	switch {
+	case t.service != "":
+		return fmt.Sprintf("service/%s/%s", t.namespace, t.service)
+	case t.pod != "":
+		return fmt.Sprintf("pod/%s/%s", t.namespace, t.pod)
+	}
+	return fmt.Sprintf("pod/%s -l %s", t.namespace, t.labelSelector)
+}
+
+// resolvePortForwardTarget returns the pod to forward to and its port. Like kubectl
+// port-forward, a service resolves to a pod it selects and the target port of the
+// service port, the first one when the remote port is not set.
+func resolvePortForwardTarget(ctx context.Context, client restclient.Interface, target portForwardTarget) (*corev1.Pod, int, error) {
+	if target.service == "" {
+		pod, err := selectPod(ctx, client, target.namespace, target.pod, target.labelSelector)
+		if err != nil {
+			return nil, 0, err
+		}
+		port, err := containerPort(pod, target.remotePort)
+		return pod, port, err
+	}
+
+	service := &corev1.Service{}
+	err := client.Get().
+		Namespace(target.namespace).
+		Resource("services").
+		Name(target.service).
+		Context(ctx).
+		Do().
+		Into(service)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(service.Spec.Selector) == 0 {
+		return nil, 0, fmt.Errorf("service %s/%s has no selector to find a pod with", target.namespace, target.service)
+	}
+	var servicePort *corev1.ServicePort
+	for i, port := range service.Spec.Ports {
+		if target.remotePort == "" || target.remotePort == port.Name || target.remotePort == strconv.Itoa(int(port.Port)) {
+			servicePort = &service.Spec.Ports[i]
+			break
+		}
+	}
+	if servicePort == nil {
+		return nil, 0, fmt.Errorf("service %s/%s has no port %q", target.namespace, target.service, target.remotePort)
+	}
+
+	pod, err := selectPod(ctx, client, target.namespace, "", labels.SelectorFromSet(service.Spec.Selector).String())
+	if err != nil {
+		return nil, 0, err
+	}
+	switch {
+	case servicePort.TargetPort.Type == intstr.String:
+		port, err := containerPort(pod, servicePort.TargetPort.StrVal)
+		return pod, port, err
+	case servicePort.TargetPort.IntVal != 0:
+		return pod, int(servicePort.TargetPort.IntVal), nil
+	}
+	return pod, int(servicePort.Port), nil
+}
+
+// containerPort returns the port of the given number or container port name.
+func containerPort(pod *corev1.Pod, port string) (int, error) {
+	if number, err := strconv.Atoi(port); err == nil {
+		return number, nil
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			if containerPort.Name == port {
+				return int(containerPort.ContainerPort), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("pod %s/%s has no container port %q", pod.Namespace, pod.Name, port)
+}
+
+// startPortForward forwards localHost:localPort, or a free port when 0, to the target
+// until stopCtx is done. When the connection to the pod is lost, e.g. as it restarted,
+// the target is resolved again and the same local port forwarded to it.
+func (p *KubeProvider) startPortForward(ctx, stopCtx context.Context, client restclient.Interface, target portForwardTarget, localHost string, localPort int) (*portForward, error) {
+	pod, remotePort, err := resolvePortForwardTarget(ctx, client, target)
+	if err != nil {
+		return nil, err
+	}
+
+	stopCtx, stop := context.WithCancel(stopCtx)
+	stopChan := make(chan struct{})
+	go func() {
+		<-stopCtx.Done()
+		close(stopChan)
+	}()
+
+	forwarder, lost, err := p.forwardPort(ctx, client, target.namespace, pod.Name, localHost, localPort, remotePort, stopChan)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	localPort = int(ports[0].Local)
+	log.Printf("[INFO] Forwarding %s:%d to %s, pod %s port %d", localHost, localPort, target, pod.Name, remotePort)
+
+	go func() {
+		reconnect := backoff.NewExponentialBackOff()
+		reconnect.MaxElapsedTime = 0
+		for {
+			select {
+			case <-stopCtx.Done():
+				return
+			case err := <-lost:
+				if stopCtx.Err() != nil {
+					return
+				}
+				log.Printf("[WARN] Lost the port-forward of %s:%d to %s, reconnecting: %v", localHost, localPort, target, err)
+			}
+
+			for {
+				select {
+				case <-stopCtx.Done():
+					return
+				case <-time.After(reconnect.NextBackOff()):
+				}
+				pod, remotePort, err := resolvePortForwardTarget(stopCtx, client, target)
+				if err == nil {
+					_, lost, err = p.forwardPort(stopCtx, client, target.namespace, pod.Name, localHost, localPort, remotePort, stopChan)
+				}
+				if err == nil {
+					log.Printf("[INFO] Reconnected the port-forward of %s:%d to %s, pod %s port %d", localHost, localPort, target, pod.Name, remotePort)
+					reconnect.Reset()
+					break
+				}
+				log.Printf("[WARN] Failed to reconnect the port-forward of %s:%d to %s: %v", localHost, localPort, target, err)
+			}
+		}
+	}()
+
+	return &portForward{
+		localHost: localHost,
+		localPort: localPort,
+		podName:   pod.Name,
+		stop:      stop,
+	}, nil
+}
+
+// forwardPort forwards the local port to the port of the pod until stopChan is
+// closed, returning once the local port listens. The connection being lost is
+// reported on the returned channel.
+func (p *KubeProvider) forwardPort(ctx context.Context, client restclient.Interface, namespace, pod, localHost string, localPort, remotePort int, stopChan chan struct{}) (*portforward.PortForwarder, <-chan error, error) {
+	if err := p.checkStreamingProxy(fmt.Sprintf("forwarding port %d of pod %s/%s", remotePort, namespace, pod)); err != nil {
+		return nil, nil, err
+	}
+	config := p.RestConfig
+	transport, upgrader, err := spdy.RoundTripperFor(&config)
+	if err != nil {
+		return nil, nil, err
+	}
+	url := client.Post().
+		Namespace(namespace).
+		Resource("pods").
+		Name(pod).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url)
+
+	ready := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{localHost}, []string{fmt.Sprintf("%d:%d", localPort, remotePort)}, stopChan, ready, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lost := make(chan error, 1)
+	go func() {
+		err := forwarder.ForwardPorts()
+		if err == nil {
+			err = fmt.Errorf("lost connection to pod %s/%s", namespace, pod)
+		}
+		lost <- err
+	}()
+	select {
+	case <-ready:
+		return forwarder, lost, nil
+	case err := <-lost:
+		return nil, nil, fmt.Errorf("failed to forward %s:%d to pod %s/%s port %d: %w", localHost, localPort, namespace, pod, remotePort, err)
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
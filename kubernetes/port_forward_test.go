@@ -0,0 +1,103 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+func Test_resolvePortForwardTarget(t *testing.T) {
+	pod := corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: "postgres-0", Namespace: "db"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:  "postgres",
+			Ports: []corev1.ContainerPort{{Name: "postgres", ContainerPort: 5432}, {Name: "metrics", ContainerPort: 9187}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	service := corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "postgres", Namespace: "db"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "postgres"},
+			Ports: []corev1.ServicePort{
+				{Name: "tcp-postgres", Port: 5432, TargetPort: intstr.FromString("postgres")},
+				{Name: "http-metrics", Port: 80, TargetPort: intstr.FromInt(9187)},
+				{Name: "legacy", Port: 6432},
+			},
+		},
+	}
+
+	var selector string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/namespaces/db/services/postgres":
+			_ = json.NewEncoder(w).Encode(&service)
+		case "/api/v1/namespaces/db/pods/postgres-0":
+			_ = json.NewEncoder(w).Encode(&pod)
+		case "/api/v1/namespaces/db/pods":
+			selector = r.URL.Query().Get("labelSelector")
+			_ = json.NewEncoder(w).Encode(&corev1.PodList{Items: []corev1.Pod{pod}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		given     portForwardTarget
+		thenPort  int
+		thenError bool
+	}{
+		{"validate the first service port by default", portForwardTarget{namespace: "db", service: "postgres"}, 5432, false},
+		{"validate service port by name with a numeric target port", portForwardTarget{namespace: "db", service: "postgres", remotePort: "http-metrics"}, 9187, false},
+		{"validate service port by number without target port", portForwardTarget{namespace: "db", service: "postgres", remotePort: "6432"}, 6432, false},
+		{"validate missing service port", portForwardTarget{namespace: "db", service: "postgres", remotePort: "redis"}, 0, true},
+		{"validate pod port by name", portForwardTarget{namespace: "db", pod: "postgres-0", remotePort: "metrics"}, 9187, false},
+		{"validate pod port by number", portForwardTarget{namespace: "db", labelSelector: "app=postgres", remotePort: "5432"}, 5432, false},
+		{"validate missing pod port", portForwardTarget{namespace: "db", pod: "postgres-0", remotePort: "redis"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, port, err := resolvePortForwardTarget(context.Background(), client.CoreV1().RESTClient(), tt.given)
+			if (err != nil) != tt.thenError {
+				t.Fatalf("resolvePortForwardTarget() error = %v, want error %v", err, tt.thenError)
+			}
+			if err == nil && (selected.Name != "postgres-0" || port != tt.thenPort) {
+				t.Errorf("resolvePortForwardTarget() = %s port %d, want postgres-0 port %d", selected.Name, port, tt.thenPort)
+			}
+		})
+	}
+	if selector != "app=postgres" {
+		t.Errorf("resolvePortForwardTarget() selected pods with %q, want the selector of the service", selector)
+	}
+}
+
+func TestKubeProvider_forwardPort_proxy(t *testing.T) {
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &KubeProvider{proxyURL: "http://proxy.example.com:3128"}
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	_, _, err = provider.forwardPort(context.Background(), client.CoreV1().RESTClient(), "vault", "vault-0", "127.0.0.1", 0, 8200, stopChan)
+	if err == nil || !strings.Contains(err.Error(), "proxy_url") {
+		t.Errorf("forwardPort() error = %v, want an error naming proxy_url", err)
+	}
+}
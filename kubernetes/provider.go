@@ -4,13 +4,20 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/mitchellh/go-homedir"
+	"golang.org/x/net/http/httpguts"
+	"io/ioutil"
 	"k8s.io/apimachinery/pkg/api/meta"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	k8sresource "k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/discovery"
 	diskcached "k8s.io/client-go/discovery/cached/disk"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	restclient "k8s.io/client-go/rest"
@@ -19,11 +26,13 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	aggregator "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -31,10 +40,45 @@ func Provider() *schema.Provider {
 	p := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"apply_retry_count": {
-				Type:        schema.TypeInt,
-				Optional:    true,
-				DefaultFunc: func() (interface{}, error) { return 1, nil },
-				Description: "Defines the number of attempts any create/update action will take",
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ConflictsWith: []string{"read_retry_count"},
+				ValidateFunc:  validation.IntAtLeast(0),
+				Deprecated:    "Use read_retry_count instead",
+				Description:   "Deprecated alias of read_retry_count",
+			},
+			"read_retry_count": {
+				Type:     schema.TypeInt,
+				Optional: true,
+				DefaultFunc: schema.MultiEnvDefaultFunc(
+					[]string{
+						"KUBECTL_PROVIDER_READ_RETRY_COUNT",
+						"KUBECTL_PROVIDER_APPLY_RETRY_COUNT",
+					},
+					1),
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Defines the number of times a read from the Kubernetes master is retried with exponential backoff on transient errors (timeouts, 429, 5xx, connection refused)",
+			},
+			"qps": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBE_QPS", 100.0),
+				ValidateFunc: validation.FloatAtLeast(0.01),
+				Description:  "Maximum queries per second to the Kubernetes master allowed by the client-side rate limiter.",
+			},
+			"burst": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBE_BURST", 100),
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum burst of queries to the Kubernetes master allowed by the client-side rate limiter.",
+			},
+			"rate_limiter": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBE_RATE_LIMITER", rateLimiterTokenBucket),
+				ValidateFunc: validation.StringInSlice([]string{rateLimiterTokenBucket, rateLimiterNone}, false),
+				Description:  "Client-side rate limiter, either token_bucket (limited by qps and burst) or none to rely on the server-side API Priority and Fairness only.",
 			},
 			"host": {
 				Type:        schema.TypeString,
@@ -87,7 +131,20 @@ func Provider() *schema.Provider {
 						"KUBECONFIG",
 					},
 					"~/.kube/config"),
-				Description: "Path to the kube config file, defaults to ~/.kube/config",
+				Description: "Path to the kube config file, defaults to ~/.kube/config. Multiple paths separated by the OS path list separator are merged like KUBECONFIG",
+			},
+			"config_paths": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "List of paths to kube config files, merged in order like KUBECONFIG. Takes precedence over config_path",
+			},
+			"config_raw": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_CONFIG_RAW", ""),
+				Description: "Raw kube config content, used instead of config_path and config_paths when set",
 			},
 			"config_context": {
 				Type:        schema.TypeString,
@@ -106,12 +163,117 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("KUBE_CTX_CLUSTER", ""),
 				Description: "",
 			},
+			"namespace": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_NAMESPACE", ""),
+				Description: "Namespace queried by default, defaults to the namespace of the kube config context, or of the service account when in-cluster",
+			},
 			"token": {
 				Type:        schema.TypeString,
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("KUBE_TOKEN", ""),
 				Description: "Token to authentifcate an service account",
 			},
+			"token_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_TOKEN_FILE", ""),
+				Description: "Path to a file holding the token to authenticate with. The file is re-read periodically, so rotated tokens such as bound service account tokens are picked up. Takes precedence over token",
+			},
+			"oidc": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"issuer_url": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"client_id": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"client_secret": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"refresh_token": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"id_token": {
+							Type:      schema.TypeString,
+							Optional:  true,
+							Sensitive: true,
+						},
+						"certificate_authority": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "PEM-encoded root certificates bundle of the issuer.",
+						},
+						"extra_scopes": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+				Description: "Authenticate with OpenID Connect, refreshing the ID token with the refresh token when it expires.",
+			},
+			"proxy_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_PROXY_URL", ""),
+				Description: "URL of the HTTP, HTTPS or SOCKS5 proxy to reach the Kubernetes master through.",
+			},
+			"tls_server_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_TLS_SERVER_NAME", ""),
+				Description: "Server name used for SNI and to verify the TLS certificate, instead of the hostname of the Kubernetes master.",
+			},
+			"timeout": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_REQUEST_TIMEOUT", ""),
+				Description: "Timeout of a single request to the Kubernetes master, e.g. 30s. Unset means no timeout.",
+			},
+			"headers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Additional HTTP headers sent with every request to the Kubernetes master.",
+			},
+			"in_cluster": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_IN_CLUSTER", false),
+				Description: "Use the service account of the pod Terraform runs in. Without a kube config or host, the in-cluster config is used automatically when available.",
+			},
+			"discovery_cache": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBE_DISCOVERY_CACHE", discoveryCacheDisk),
+				ValidateFunc: validation.StringInSlice([]string{discoveryCacheDisk, discoveryCacheMemory}, false),
+				Description:  "Where API discovery results are cached, either disk (shared with kubectl) or memory (for read-only home directories).",
+			},
+			"discovery_cache_dir": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("KUBE_DISCOVERY_CACHE_DIR", ""),
+				Description: "Directory of the disk discovery cache, defaults to the kubectl cache under ~/.kube.",
+			},
+			"discovery_cache_ttl": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("KUBE_DISCOVERY_CACHE_TTL", "10m"),
+				ValidateFunc: validateDuration,
+				Description:  "How long the disk discovery cache is valid, e.g. 10m.",
+			},
 			"load_config_file": {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -152,15 +314,41 @@ func Provider() *schema.Provider {
 			"kubectl-query_server_version": dataSourceKubectlServerVersion(),
 			"kubectl-query_services":       dataSourceKubectlServices(),
 			"kubectl-query_pods":       	dataSourceKubectlPods(),
+			"kubectl-query_access_review":  dataSourceKubectlAccessReview(),
+			"kubectl-query_whoami":         dataSourceKubectlWhoami(),
+			"kubectl-query_pod_logs":       dataSourceKubectlPodLogs(),
+			"kubectl-query_pod_file":       dataSourceKubectlPodFile(),
+			"kubectl-query_http_probe":     dataSourceKubectlHTTPProbe(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
 			"kubectl-query_server_version": resourceKubectlServerVersion(),
 			"kubectl-query_services":       resourceKubectlServices(),
 			"kubectl-query_pods":       	resourceKubectlPods(),
+			"kubectl-query_access_review":  resourceKubectlAccessReview(),
+			"kubectl-query_whoami":         resourceKubectlWhoami(),
+			"kubectl-query_pod_logs":       resourceKubectlPodLogs(),
+			"kubectl-query_pod_exec":       resourceKubectlPodExec(),
+			"kubectl-query_pod_file":       resourceKubectlPodFile(),
+			"kubectl-query_http_probe":     resourceKubectlHTTPProbe(),
+			"kubectl-query_port_forward":   resourceKubectlPortForward(),
 		},
 	}
 
+	// Unknown values, such as the host of a cluster created in the same apply, read as empty
+	// values from the ResourceData. Only the diff suppression sees the placeholder Terraform
+	// sends for them, so record which connection attributes are unknown there.
+	var unknownAttributes []string
+	for _, k := range connectionAttributes {
+		k := k
+		p.Schema[k].DiffSuppressFunc = func(_, _, new string, _ *schema.ResourceData) bool {
+			if new == unknownConfigValue {
+				unknownAttributes = append(unknownAttributes, k)
+			}
+			return false
+		}
+	}
+
 	p.ConfigureContextFunc = func(context context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 		terraformVersion := p.TerraformVersion
 		if terraformVersion == "" {
@@ -168,20 +356,94 @@ func Provider() *schema.Provider {
 			// We can therefore assume that if it's missing it's 0.10 or 0.11
 			terraformVersion = "0.11+compatible"
 		}
-		return providerConfigure(d, terraformVersion)
+		provider, diags := providerConfigure(d, terraformVersion)
+		if provider != nil && len(unknownAttributes) > 0 {
+			log.Printf("[INFO] Provider configuration is not known yet (%s), deferring reads", strings.Join(unknownAttributes, ", "))
+			provider.(*KubeProvider).configUnknown = true
+		}
+		unknownAttributes = nil
+		// Only the configure request carries the context cancelled when Terraform stops the provider
+		if stopCtx, ok := schema.StopContext(context); ok && provider != nil {
+			provider.(*KubeProvider).stopCtx = stopCtx
+		}
+		return provider, diags
 	}
 
 	return p
 }
 
+const (
+	discoveryCacheDisk   = "disk"
+	discoveryCacheMemory = "memory"
+)
+
+// unknownConfigValue is the placeholder Terraform uses for configuration values
+// which are not known until apply.
+const unknownConfigValue = "74D93920-ED26-11E3-AC10-0800200C9A66"
+
+// connectionAttributes are the provider attributes which decide which cluster is
+// queried and how, making reads meaningless while any of them is unknown.
+var connectionAttributes = []string{
+	"host",
+	"username",
+	"password",
+	"client_certificate",
+	"client_key",
+	"cluster_ca_certificate",
+	"config_path",
+	"config_raw",
+	"config_context",
+	"config_context_auth_info",
+	"config_context_cluster",
+	"token",
+	"token_file",
+	"namespace",
+}
+
 type KubeProvider struct {
-	MainClientset       *kubernetes.Clientset
-	RestConfig          restclient.Config
-	AggregatorClientset *aggregator.Clientset
+	RestConfig restclient.Config
+
+	// configUnknown is set when the provider was configured with values which are
+	// not known until apply, e.g. while planning the creation of the cluster.
+	configUnknown bool
+
+	clientsetsLock      sync.Mutex
+	mainClientset       *kubernetes.Clientset
+	aggregatorClientset *aggregator.Clientset
+	dynamicClient       dynamic.Interface
+	discoveryClient     discovery.CachedDiscoveryInterface
+
+	discoveryCache    string
+	discoveryCacheDir string
+	discoveryCacheTTL time.Duration
+
+	// namespace is queried by reads which don't set their own
+	namespace string
+
+	// stopCtx is done when Terraform stops the provider, ending work outliving a request
+	stopCtx context.Context
+
+	// proxyURL is the proxy_url the transport was wrapped with, which the SPDY
+	// connections of exec and port-forward can't use.
+	proxyURL string
+
+	configPaths          []string
+	configRaw            string
+	readRetryCount       uint64
+	contextProviders     map[string]*KubeProvider
+	contextProvidersLock sync.Mutex
 }
 
 var _ k8sresource.RESTClientGetter = &KubeProvider{}
 
+// stopContext returns the context done when Terraform stops the provider.
+func (p *KubeProvider) stopContext() context.Context {
+	if p.stopCtx == nil {
+		return context.Background()
+	}
+	return p.stopCtx
+}
+
 func (p *KubeProvider) ToRawKubeConfigLoader() clientcmd.ClientConfig {
 	return nil
 }
@@ -190,12 +452,37 @@ func (p *KubeProvider) ToRESTConfig() (*restclient.Config, error) {
 	return &p.RestConfig, nil
 }
 
+// ToDiscoveryClient returns the cached discovery client of the provider, building it on first use.
 func (p *KubeProvider) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
-	home, _ := homedir.Dir()
-	var httpCacheDir = filepath.Join(home, ".kube", "http-cache")
+	p.clientsetsLock.Lock()
+	defer p.clientsetsLock.Unlock()
+
+	if p.discoveryClient == nil {
+		if p.discoveryCache == discoveryCacheMemory {
+			discoveryClient, err := discovery.NewDiscoveryClientForConfig(&p.RestConfig)
+			if err != nil {
+				return nil, err
+			}
+			p.discoveryClient = memory.NewMemCacheClient(discoveryClient)
+			return p.discoveryClient, nil
+		}
+
+		home, _ := homedir.Dir()
+		var httpCacheDir = filepath.Join(home, ".kube", "http-cache")
+		discoveryCacheParentDir := filepath.Join(home, ".kube", "cache", "discovery")
+		if p.discoveryCacheDir != "" {
+			httpCacheDir = filepath.Join(p.discoveryCacheDir, "http")
+			discoveryCacheParentDir = filepath.Join(p.discoveryCacheDir, "discovery")
+		}
 
-	discoveryCacheDir := computeDiscoverCacheDir(filepath.Join(home, ".kube", "cache", "discovery"), p.RestConfig.Host)
-	return diskcached.NewCachedDiscoveryClientForConfig(&p.RestConfig, discoveryCacheDir, httpCacheDir, 10*time.Minute)
+		discoveryCacheDir := computeDiscoverCacheDir(discoveryCacheParentDir, p.RestConfig.Host)
+		discoveryClient, err := newDiscoveryClient(&p.RestConfig, discoveryCacheDir, httpCacheDir, p.discoveryCacheTTL)
+		if err != nil {
+			return nil, err
+		}
+		p.discoveryClient = discoveryClient
+	}
+	return p.discoveryClient, nil
 }
 
 func (p *KubeProvider) ToRESTMapper() (meta.RESTMapper, error) {
@@ -209,32 +496,51 @@ func (p *KubeProvider) ToRESTMapper() (meta.RESTMapper, error) {
 	return nil, fmt.Errorf("no restmapper")
 }
 
-var kubectlApplyRetryCount uint64
-
 func providerConfigure(d *schema.ResourceData, terraformVersion string) (interface{}, diag.Diagnostics) {
 
 	var cfg *restclient.Config
+	var namespace string
 	var err error
-	if d.Get("load_config_file").(bool) {
+	if d.Get("in_cluster").(bool) {
+		// In-cluster config loading
+		cfg, err = inClusterConfig()
+		if err != nil {
+			err = fmt.Errorf("failed to load in-cluster config: %s", err)
+		}
+		namespace = inClusterNamespace()
+	} else if _, ok := d.GetOk("config_raw"); ok {
+		// Inline config loading
+		cfg, namespace, err = tryLoadingRawConfig(d)
+	} else if d.Get("load_config_file").(bool) {
 		// Config file loading
-		cfg, err = tryLoadingConfigFile(d)
-	}
-
-	kubectlApplyRetryCount = uint64(d.Get("apply_retry_count").(int))
-	if os.Getenv("KUBECTL_PROVIDER_APPLY_RETRY_COUNT") != "" {
-		applyEnvValue, _ := strconv.Atoi(os.Getenv("KUBECTL_PROVIDER_APPLY_RETRY_COUNT"))
-		kubectlApplyRetryCount = uint64(applyEnvValue)
+		cfg, namespace, err = tryLoadingConfigFile(d)
 	}
 
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
+	if _, ok := d.GetOk("host"); cfg == nil && !ok {
+		// Fall back to the service account of the pod Terraform runs in, as kubectl does,
+		// rather than an empty config pointed at localhost
+		if cfg, err = inClusterConfig(); err == nil {
+			log.Printf("[INFO] No kube config found, using the in-cluster config of %q", cfg.Host)
+			namespace = inClusterNamespace()
+		} else {
+			cfg = nil
+		}
+	}
 	if cfg == nil {
 		cfg = &restclient.Config{}
 	}
 
-	cfg.QPS = 100.0
-	cfg.Burst = 100
+	cfg.QPS = float32(d.Get("qps").(float64))
+	cfg.Burst = d.Get("burst").(int)
+	if d.Get("rate_limiter").(string) == rateLimiterNone {
+		// a negative QPS disables the client-side rate limiter of client-go
+		cfg.QPS = -1
+	} else {
+		cfg.RateLimiter = newThrottleLoggingRateLimiter(cfg.QPS, cfg.Burst)
+	}
 
 	// Overriding with static configuration
 	cfg.UserAgent = fmt.Sprintf("HashiCorp/1.0 Terraform/%s", terraformVersion)
@@ -263,6 +569,26 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 	if v, ok := d.GetOk("token"); ok {
 		cfg.BearerToken = v.(string)
 	}
+	if v, ok := d.GetOk("token_file"); ok {
+		path, err := homedir.Expand(v.(string))
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		// client-go prefers a static token until the first refresh from the file
+		cfg.BearerToken = ""
+		cfg.BearerTokenFile = path
+	}
+	if v, ok := d.GetOk("oidc"); ok {
+		spec, ok := v.([]interface{})[0].(map[string]interface{})
+		if !ok {
+			return nil, diag.FromErr(fmt.Errorf("failed to parse oidc"))
+		}
+		cfg.AuthProvider = expandOIDCAuthProvider(spec)
+		cfg.AuthConfigPersister = &memoryAuthConfigPersister{}
+	}
+	if diags := configureTransport(d, cfg); diags.HasError() {
+		return nil, diags
+	}
 
 	if v, ok := d.GetOk("exec"); ok {
 		exec := &clientcmdapi.ExecConfig{}
@@ -279,73 +605,499 @@ func providerConfigure(d *schema.ResourceData, terraformVersion string) (interfa
 		cfg.ExecProvider = exec
 	}
 
-	k, err := kubernetes.NewForConfig(cfg)
+	provider := newKubeProvider(cfg)
+	provider.configPaths, err = configPathsFromResourceData(d)
 	if err != nil {
-		return nil, diag.FromErr(fmt.Errorf("failed to configure: %s", err))
+		return nil, diag.FromErr(err)
+	}
+	provider.configRaw = d.Get("config_raw").(string)
+	provider.proxyURL = d.Get("proxy_url").(string)
+	provider.namespace = namespace
+	if v, ok := d.GetOk("namespace"); ok {
+		provider.namespace = v.(string)
+	}
+	if provider.namespace == "" {
+		provider.namespace = "default"
+	}
+	provider.discoveryCache = d.Get("discovery_cache").(string)
+	if v, ok := d.GetOk("discovery_cache_dir"); ok {
+		if provider.discoveryCacheDir, err = homedir.Expand(v.(string)); err != nil {
+			return nil, diag.FromErr(err)
+		}
+	}
+	// validated by the schema
+	provider.discoveryCacheTTL, _ = time.ParseDuration(d.Get("discovery_cache_ttl").(string))
+	provider.readRetryCount = uint64(d.Get("read_retry_count").(int))
+	// GetOk would ignore 0, which disables retrying
+	if v, ok := d.GetOkExists("apply_retry_count"); ok {
+		provider.readRetryCount = uint64(v.(int))
 	}
+	return provider, nil
+}
+
+// inClusterConfig is a variable so tests can run outside of a pod.
+var inClusterConfig = restclient.InClusterConfig
 
-	a, err := aggregator.NewForConfig(cfg)
+// inClusterNamespaceFile holds the namespace of the service account of the pod Terraform runs in.
+var inClusterNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// inClusterNamespace returns the namespace of the pod Terraform runs in, or "" when unknown.
+func inClusterNamespace() string {
+	data, err := ioutil.ReadFile(inClusterNamespaceFile)
 	if err != nil {
-		return nil, diag.FromErr(fmt.Errorf("failed to configure: %s", err))
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// The client constructors are variables so tests can count the clients built.
+var (
+	newMainClientset       = func(cfg *restclient.Config) (*kubernetes.Clientset, error) { return kubernetes.NewForConfig(cfg) }
+	newAggregatorClientset = func(cfg *restclient.Config) (*aggregator.Clientset, error) { return aggregator.NewForConfig(cfg) }
+	newDynamicClient       = func(cfg *restclient.Config) (dynamic.Interface, error) { return dynamic.NewForConfig(cfg) }
+	newDiscoveryClient     = func(cfg *restclient.Config, discoveryCacheDir, httpCacheDir string, ttl time.Duration) (discovery.CachedDiscoveryInterface, error) {
+		return diskcached.NewCachedDiscoveryClientForConfig(cfg, discoveryCacheDir, httpCacheDir, ttl)
 	}
+)
 
+// newKubeProvider creates a provider for cfg. Clients are built on first use, so
+// configuring the provider never needs the cluster to exist.
+func newKubeProvider(cfg *restclient.Config) *KubeProvider {
 	// dereference config to create a shallow copy, allowing each func
 	// to manipulate the state without affecting another func
 	return &KubeProvider{
-		MainClientset:       k,
-		RestConfig:          *cfg,
-		AggregatorClientset: a,
-	}, nil
+		RestConfig: *cfg,
+	}
 }
 
-func tryLoadingConfigFile(d *schema.ResourceData) (*restclient.Config, error) {
-	path, err := homedir.Expand(d.Get("config_path").(string))
+// MainClientset returns the clientset for the core Kubernetes APIs, building it on first use.
+func (p *KubeProvider) MainClientset() (*kubernetes.Clientset, error) {
+	p.clientsetsLock.Lock()
+	defer p.clientsetsLock.Unlock()
+
+	if p.mainClientset == nil {
+		k, err := newMainClientset(&p.RestConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure: %s", err)
+		}
+		p.mainClientset = k
+	}
+	return p.mainClientset, nil
+}
+
+// AggregatorClientset returns the clientset for the aggregated APIs, building it on first use.
+func (p *KubeProvider) AggregatorClientset() (*aggregator.Clientset, error) {
+	p.clientsetsLock.Lock()
+	defer p.clientsetsLock.Unlock()
+
+	if p.aggregatorClientset == nil {
+		a, err := newAggregatorClientset(&p.RestConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure: %s", err)
+		}
+		p.aggregatorClientset = a
+	}
+	return p.aggregatorClientset, nil
+}
+
+// DynamicClient returns the client for arbitrary resources, building it on first use.
+func (p *KubeProvider) DynamicClient() (dynamic.Interface, error) {
+	p.clientsetsLock.Lock()
+	defer p.clientsetsLock.Unlock()
+
+	if p.dynamicClient == nil {
+		c, err := newDynamicClient(&p.RestConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure: %s", err)
+		}
+		p.dynamicClient = c
+	}
+	return p.dynamicClient, nil
+}
+
+// skipReadForUnknownConfig decides what a read does while the provider configuration
+// is unknown. Resources keep their last read state until the apply, when the
+// configuration is known. Data sources must return known values, so they fail with
+// an explanation rather than querying a default cluster on localhost.
+func (p *KubeProvider) skipReadForUnknownConfig(d *schema.ResourceData) (bool, error) {
+	if !p.configUnknown {
+		return false, nil
+	}
+	if d.Id() != "" {
+		log.Printf("[INFO] Provider configuration is not known yet, keeping the last read state of %s", d.Id())
+		return true, nil
+	}
+	return true, fmt.Errorf("the provider configuration is not known until apply, e.g. because the cluster is created in the same apply. " +
+		"Use the resource of the same name, which is read during apply, or add depends_on to defer this data source")
+}
+
+// configureTransport applies the proxy, TLS server name, timeout and header settings
+// to cfg, reporting invalid values against the attribute they came from.
+func configureTransport(d *schema.ResourceData, cfg *restclient.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if v, ok := d.GetOk("proxy_url"); ok {
+		proxyURL, err := url.Parse(v.(string))
+		if err != nil || proxyURL.Host == "" {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid proxy_url",
+				Detail:        fmt.Sprintf("%q is not a valid proxy URL, expected e.g. http://proxy.example.com:3128 or socks5://localhost:1080", v.(string)),
+				AttributePath: cty.GetAttrPath("proxy_url"),
+			})
+		} else if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" && proxyURL.Scheme != "socks5" {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Unsupported proxy_url scheme",
+				Detail:        fmt.Sprintf("The proxy scheme %q is not supported, use one of http, https or socks5", proxyURL.Scheme),
+				AttributePath: cty.GetAttrPath("proxy_url"),
+			})
+		} else {
+			cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+				if t, ok := rt.(*http.Transport); ok {
+					// the transport is shared through the client-go TLS cache, so proxy a copy of it
+					t = t.Clone()
+					t.Proxy = http.ProxyURL(proxyURL)
+					return t
+				}
+				log.Printf("[WARN] Unable to set proxy_url on transport of type %T", rt)
+				return rt
+			})
+		}
+	}
+
+	if v, ok := d.GetOk("tls_server_name"); ok {
+		cfg.TLSClientConfig.ServerName = v.(string)
+	}
+
+	if v, ok := d.GetOk("timeout"); ok {
+		timeout, err := time.ParseDuration(v.(string))
+		if err != nil || timeout < 0 {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid timeout",
+				Detail:        fmt.Sprintf("%q is not a valid timeout, expected a positive duration such as 30s or 2m", v.(string)),
+				AttributePath: cty.GetAttrPath("timeout"),
+			})
+		} else {
+			cfg.Timeout = timeout
+		}
+	}
+
+	if v, ok := d.GetOk("headers"); ok {
+		headers := http.Header{}
+		for name, value := range v.(map[string]interface{}) {
+			if !httpguts.ValidHeaderFieldName(name) {
+				diags = append(diags, diag.Diagnostic{
+					Severity:      diag.Error,
+					Summary:       "Invalid header name",
+					Detail:        fmt.Sprintf("%q is not a valid HTTP header name", name),
+					AttributePath: cty.GetAttrPath("headers").IndexString(name),
+				})
+				continue
+			}
+			headers.Set(name, value.(string))
+		}
+		cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &headersRoundTripper{headers: headers, rt: rt}
+		})
+	}
+
+	return diags
+}
+
+// headersRoundTripper adds a fixed set of headers to every request.
+type headersRoundTripper struct {
+	headers http.Header
+	rt      http.RoundTripper
+}
+
+func (h *headersRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = utilnet.CloneRequest(req)
+	for name, values := range h.headers {
+		req.Header[name] = values
+	}
+	return h.rt.RoundTrip(req)
+}
+
+func (h *headersRoundTripper) WrappedRoundTripper() http.RoundTripper { return h.rt }
+
+// kubeconfigOverrideSchema is the optional block data sources use to query a
+// different kubeconfig context than the one the provider is configured with.
+func kubeconfigOverrideSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		ForceNew: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"config_path": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "Path to the kube config file, defaults to the config_path of the provider",
+				},
+				"context": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+				"context_auth_info": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+				"context_cluster": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+			},
+		},
+		Description: "Query the cluster of another kubeconfig context instead of the provider's one.",
+	}
+}
+
+func namespaceSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		ForceNew:    true,
+		Description: "Namespace to query, defaults to the namespace of the provider.",
+	}
+}
+
+// effectiveNamespaceSchema exports the namespace queried, which namespace can't hold
+// itself without its default being read back from the state on refresh.
+func effectiveNamespaceSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "Namespace queried, either namespace or the namespace of the provider.",
+	}
+}
+
+// namespaceFromResourceData returns the namespace to query, falling back to the
+// one of the provider when the data source doesn't set it.
+func namespaceFromResourceData(d *schema.ResourceData, provider *KubeProvider) string {
+	if v, ok := d.GetOk("namespace"); ok {
+		return v.(string)
+	}
+	return provider.namespace
+}
+
+// kubeProviderFromResourceData returns the provider to query with, honouring
+// the kubeconfig override block of the data source when it is set.
+func kubeProviderFromResourceData(d *schema.ResourceData, meta interface{}) (*KubeProvider, error) {
+	provider := meta.(*KubeProvider)
+
+	v, ok := d.GetOk("kubeconfig")
+	if !ok {
+		return provider, nil
+	}
+	spec, ok := v.([]interface{})[0].(map[string]interface{})
+	if !ok {
+		return provider, nil
+	}
+	return provider.forContext(
+		spec["config_path"].(string),
+		spec["context"].(string),
+		spec["context_auth_info"].(string),
+		spec["context_cluster"].(string),
+	)
+}
+
+// forContext builds a provider for the given kubeconfig context, caching it so
+// repeated reads against the same context share a client.
+func (p *KubeProvider) forContext(configPath, ctx, authInfo, cluster string) (*KubeProvider, error) {
+	paths := p.configPaths
+	if configPath != "" {
+		var err error
+		if paths, err = expandConfigPaths(filepath.SplitList(configPath)); err != nil {
+			return nil, err
+		}
+	}
+
+	key := strings.Join(append(append([]string{}, paths...), ctx, authInfo, cluster), "\x00")
+	p.contextProvidersLock.Lock()
+	defer p.contextProvidersLock.Unlock()
+	if provider, ok := p.contextProviders[key]; ok {
+		return provider, nil
+	}
+
+	var cfg *restclient.Config
+	var namespace string
+	var err error
+	if configPath == "" && p.configRaw != "" {
+		cfg, namespace, err = loadRawKubeConfig(p.configRaw, ctx, authInfo, cluster)
+	} else {
+		cfg, namespace, err = loadKubeConfig(paths, ctx, authInfo, cluster)
+	}
 	if err != nil {
 		return nil, err
 	}
+	if cfg == nil {
+		return nil, fmt.Errorf("failed to load config: %s does not exist", strings.Join(paths, ", "))
+	}
+	cfg.QPS = p.RestConfig.QPS
+	cfg.Burst = p.RestConfig.Burst
+	if p.RestConfig.RateLimiter != nil {
+		cfg.RateLimiter = newThrottleLoggingRateLimiter(cfg.QPS, cfg.Burst)
+	}
+	cfg.UserAgent = p.RestConfig.UserAgent
+	cfg.Timeout = p.RestConfig.Timeout
+	cfg.WrapTransport = p.RestConfig.WrapTransport
+
+	provider := newKubeProvider(cfg)
+	provider.configPaths = paths
+	provider.namespace = namespace
+	if provider.namespace == "" {
+		provider.namespace = "default"
+	}
+	provider.proxyURL = p.proxyURL
+	provider.stopCtx = p.stopCtx
+	provider.readRetryCount = p.readRetryCount
+	provider.discoveryCache = p.discoveryCache
+	provider.discoveryCacheDir = p.discoveryCacheDir
+	provider.discoveryCacheTTL = p.discoveryCacheTTL
+	if p.contextProviders == nil {
+		p.contextProviders = map[string]*KubeProvider{}
+	}
+	p.contextProviders[key] = provider
+	return provider, nil
+}
+
+func tryLoadingConfigFile(d *schema.ResourceData) (*restclient.Config, string, error) {
+	paths, err := configPathsFromResourceData(d)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, _ := d.GetOk("config_context")
+	authInfo, _ := d.GetOk("config_context_auth_info")
+	cluster, _ := d.GetOk("config_context_cluster")
+	return loadKubeConfig(paths, ctx.(string), authInfo.(string), cluster.(string))
+}
+
+// configPathsFromResourceData returns the kubeconfig files to load. config_paths
+// wins over config_path, which is split like KUBECONFIG using the OS path list separator.
+func configPathsFromResourceData(d *schema.ResourceData) ([]string, error) {
+	if v, ok := d.GetOk("config_paths"); ok {
+		return expandConfigPaths(expandStringSlice(v.([]interface{})))
+	}
+	return expandConfigPaths(filepath.SplitList(d.Get("config_path").(string)))
+}
+
+func expandConfigPaths(paths []string) ([]string, error) {
+	expanded := []string{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		path, err := homedir.Expand(path)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, path)
+	}
+	return expanded, nil
+}
+
+func tryLoadingRawConfig(d *schema.ResourceData) (*restclient.Config, string, error) {
+	ctx, _ := d.GetOk("config_context")
+	authInfo, _ := d.GetOk("config_context_auth_info")
+	cluster, _ := d.GetOk("config_context_cluster")
+	return loadRawKubeConfig(d.Get("config_raw").(string), ctx.(string), authInfo.(string), cluster.(string))
+}
+
+// loadRawKubeConfig loads kubeconfig content, applying the context overrides when set.
+// It also returns the namespace of the selected context.
+func loadRawKubeConfig(raw, ctx, authInfo, cluster string) (*restclient.Config, string, error) {
+	overrides, ctxSuffix := kubeConfigOverrides(ctx, authInfo, cluster)
+
+	cc, err := clientcmd.NewClientConfigFromBytes([]byte(raw))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config (config_raw%s): %s", ctxSuffix, err)
+	}
+	rawConfig, err := cc.RawConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config (config_raw%s): %s", ctxSuffix, err)
+	}
+
+	cc = clientcmd.NewNonInteractiveClientConfig(rawConfig, overrides.CurrentContext, overrides, nil)
+	cfg, err := cc.ClientConfig()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config (config_raw%s): %s", ctxSuffix, err)
+	}
+	namespace, _, err := cc.Namespace()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config (config_raw%s): %s", ctxSuffix, err)
+	}
+
+	log.Printf("[INFO] Successfully loaded config (config_raw%s)", ctxSuffix)
+	return cfg, namespace, nil
+}
 
-	loader := &clientcmd.ClientConfigLoadingRules{
-		ExplicitPath: path,
+// loadKubeConfig loads the kubeconfig files at paths, applying the context overrides when set.
+// A single path is loaded explicitly, while multiple paths are merged the way kubectl merges
+// KUBECONFIG, the first file to set a value winning. Missing files are not an error and
+// result in a nil config. It also returns the namespace of the selected context.
+func loadKubeConfig(paths []string, ctx, authInfo, cluster string) (*restclient.Config, string, error) {
+	loader := &clientcmd.ClientConfigLoadingRules{}
+	if len(paths) == 1 {
+		loader.ExplicitPath = paths[0]
+	} else {
+		loader.Precedence = paths
 	}
+	path := strings.Join(paths, string(filepath.ListSeparator))
+
+	overrides, ctxSuffix := kubeConfigOverrides(ctx, authInfo, cluster)
 
+	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, overrides)
+	cfg, err := cc.ClientConfig()
+	if err != nil {
+		if pathErr, ok := err.(*os.PathError); ok && os.IsNotExist(pathErr.Err) {
+			log.Printf("[INFO] Unable to load config file as it doesn't exist at %q", path)
+			return nil, "", nil
+		}
+		if len(paths) != 1 && clientcmd.IsEmptyConfig(err) {
+			log.Printf("[INFO] Unable to load config as none of the files exist at %q", path)
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to load config (%s%s): %s", path, ctxSuffix, err)
+	}
+	namespace, _, err := cc.Namespace()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config (%s%s): %s", path, ctxSuffix, err)
+	}
+
+	log.Printf("[INFO] Successfully loaded config file (%s%s)", path, ctxSuffix)
+	return cfg, namespace, nil
+}
+
+// kubeConfigOverrides builds the context overrides, along with a suffix describing them for logs.
+func kubeConfigOverrides(ctx, authInfo, cluster string) (*clientcmd.ConfigOverrides, string) {
 	overrides := &clientcmd.ConfigOverrides{}
 	ctxSuffix := "; default context"
 
-	ctx, ctxOk := d.GetOk("config_context")
-	authInfo, authInfoOk := d.GetOk("config_context_auth_info")
-	cluster, clusterOk := d.GetOk("config_context_cluster")
-	if ctxOk || authInfoOk || clusterOk {
+	if ctx != "" || authInfo != "" || cluster != "" {
 		ctxSuffix = "; overriden context"
-		if ctxOk {
-			overrides.CurrentContext = ctx.(string)
+		if ctx != "" {
+			overrides.CurrentContext = ctx
 			ctxSuffix += fmt.Sprintf("; config ctx: %s", overrides.CurrentContext)
 			log.Printf("[DEBUG] Using custom current context: %q", overrides.CurrentContext)
 		}
 
 		overrides.Context = clientcmdapi.Context{}
-		if authInfoOk {
-			overrides.Context.AuthInfo = authInfo.(string)
+		if authInfo != "" {
+			overrides.Context.AuthInfo = authInfo
 			ctxSuffix += fmt.Sprintf("; auth_info: %s", overrides.Context.AuthInfo)
 		}
-		if clusterOk {
-			overrides.Context.Cluster = cluster.(string)
+		if cluster != "" {
+			overrides.Context.Cluster = cluster
 			ctxSuffix += fmt.Sprintf("; cluster: %s", overrides.Context.Cluster)
 		}
 		log.Printf("[DEBUG] Using overidden context: %#v", overrides.Context)
 	}
 
-	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, overrides)
-	cfg, err := cc.ClientConfig()
-	if err != nil {
-		if pathErr, ok := err.(*os.PathError); ok && os.IsNotExist(pathErr.Err) {
-			log.Printf("[INFO] Unable to load config file as it doesn't exist at %q", path)
-			return nil, nil
-		}
-		return nil, fmt.Errorf("failed to load config (%s%s): %s", path, ctxSuffix, err)
-	}
-
-	log.Printf("[INFO] Successfully loaded config file (%s%s)", path, ctxSuffix)
-	return cfg, nil
+	return overrides, ctxSuffix
 }
 
 // overlyCautiousIllegalFileCharacters matches characters that *might* not be supported.  Windows is really restrictive, so this is really restrictive
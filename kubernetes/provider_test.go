@@ -1,13 +1,24 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	aggregator "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
 )
 
 var testAccProviders map[string]*schema.Provider
@@ -42,7 +53,11 @@ func testAccCheckkubectlStatus(s *terraform.State, shouldExist bool) error {
 			continue
 		}
 
-		content, err := provider.MainClientset.RESTClient().Get().AbsPath(rs.Primary.ID).DoRaw()
+		clientset, err := provider.MainClientset()
+		if err != nil {
+			return err
+		}
+		content, err := clientset.RESTClient().Get().AbsPath(rs.Primary.ID).DoRaw()
 		if (errors.IsNotFound(err) || errors.IsGone(err)) && shouldExist {
 			return fmt.Errorf("Failed to find resource, likely a failure to create occured: %+v %v", err, string(content))
 		}
@@ -165,3 +180,506 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("KUBECONFIG must be set for acceptance tests")
 	}
 }
+
+func TestProvider_configPaths(t *testing.T) {
+	separator := string(filepath.ListSeparator)
+	tests := []struct {
+		name  string
+		given map[string]interface{}
+		then  []string
+	}{
+		{
+			"validate single config_path",
+			map[string]interface{}{"config_path": "/tmp/one"},
+			[]string{"/tmp/one"},
+		},
+		{
+			"validate config_path split like KUBECONFIG",
+			map[string]interface{}{"config_path": "/tmp/one" + separator + separator + "/tmp/two"},
+			[]string{"/tmp/one", "/tmp/two"},
+		},
+		{
+			"validate config_paths takes precedence",
+			map[string]interface{}{"config_path": "/tmp/one", "config_paths": []interface{}{"/tmp/two", "/tmp/three"}},
+			[]string{"/tmp/two", "/tmp/three"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, Provider().Schema, tt.given)
+			got, err := configPathsFromResourceData(d)
+			if err != nil {
+				t.Fatalf("configPathsFromResourceData() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.then) {
+				t.Errorf("configPathsFromResourceData() = %v, want %v", got, tt.then)
+			}
+		})
+	}
+}
+
+func TestProvider_loadKubeConfigMerged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clusters := filepath.Join(dir, "clusters")
+	if err := ioutil.WriteFile(clusters, []byte(`
+apiVersion: v1
+kind: Config
+current-context: first
+clusters:
+- name: first
+  cluster:
+    server: https://first.example.com
+contexts:
+- name: first
+  context:
+    cluster: first
+    user: first
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	users := filepath.Join(dir, "users")
+	if err := ioutil.WriteFile(users, []byte(`
+apiVersion: v1
+kind: Config
+current-context: ignored
+users:
+- name: first
+  user:
+    token: first-token
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _, err := loadKubeConfig([]string{clusters, filepath.Join(dir, "missing"), users}, "", "", "")
+	if err != nil {
+		t.Fatalf("loadKubeConfig() error = %v", err)
+	}
+	if cfg.Host != "https://first.example.com" || cfg.BearerToken != "first-token" {
+		t.Errorf("loadKubeConfig() did not merge config files, got host %q and token %q", cfg.Host, cfg.BearerToken)
+	}
+}
+
+func TestProvider_loadRawKubeConfigContext(t *testing.T) {
+	raw := `
+apiVersion: v1
+kind: Config
+current-context: first
+clusters:
+- name: first
+  cluster:
+    server: https://first.example.com
+- name: second
+  cluster:
+    server: https://second.example.com
+contexts:
+- name: first
+  context:
+    cluster: first
+- name: second
+  context:
+    cluster: second
+    namespace: monitoring
+`
+
+	cfg, namespace, err := loadRawKubeConfig(raw, "", "", "")
+	if err != nil {
+		t.Fatalf("loadRawKubeConfig() error = %v", err)
+	}
+	if cfg.Host != "https://first.example.com" {
+		t.Errorf("loadRawKubeConfig() host = %q, want the current context cluster", cfg.Host)
+	}
+	if namespace != "default" {
+		t.Errorf("loadRawKubeConfig() namespace = %q, want default for a context without namespace", namespace)
+	}
+
+	cfg, namespace, err = loadRawKubeConfig(raw, "second", "", "")
+	if err != nil {
+		t.Fatalf("loadRawKubeConfig() error = %v", err)
+	}
+	if cfg.Host != "https://second.example.com" {
+		t.Errorf("loadRawKubeConfig() host = %q, want the overridden context cluster", cfg.Host)
+	}
+	if namespace != "monitoring" {
+		t.Errorf("loadRawKubeConfig() namespace = %q, want the overridden context namespace", namespace)
+	}
+}
+
+func TestProvider_configureTransport(t *testing.T) {
+	tests := []struct {
+		name  string
+		given map[string]interface{}
+		then  int
+	}{
+		{
+			"validate supported settings",
+			map[string]interface{}{"proxy_url": "socks5://localhost:1080", "tls_server_name": "kubernetes", "timeout": "30s", "headers": map[string]interface{}{"X-Bastion": "yes"}},
+			0,
+		},
+		{
+			"validate unsupported proxy scheme",
+			map[string]interface{}{"proxy_url": "ftp://localhost:21"},
+			1,
+		},
+		{
+			"validate invalid timeout and header name",
+			map[string]interface{}{"timeout": "soon", "headers": map[string]interface{}{"X Bastion": "yes"}},
+			2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, Provider().Schema, tt.given)
+			if diags := configureTransport(d, &restclient.Config{}); len(diags) != tt.then {
+				t.Errorf("configureTransport() = %v, want %d diagnostics", diags, tt.then)
+			}
+		})
+	}
+}
+
+func TestProvider_configureUnknown(t *testing.T) {
+	tests := []struct {
+		name  string
+		given map[string]interface{}
+		then  bool
+	}{
+		{
+			"validate known configuration",
+			map[string]interface{}{"host": "https://example.com", "load_config_file": false},
+			false,
+		},
+		{
+			"validate unknown host",
+			map[string]interface{}{"host": unknownConfigValue, "load_config_file": false},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Provider()
+			if diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(tt.given)); diags.HasError() {
+				t.Fatalf("Configure() = %v", diags)
+			}
+			provider := p.Meta().(*KubeProvider)
+			if provider.configUnknown != tt.then {
+				t.Errorf("configUnknown = %v, want %v", provider.configUnknown, tt.then)
+			}
+			if provider.mainClientset != nil || provider.aggregatorClientset != nil {
+				t.Errorf("Configure() built clientsets eagerly")
+			}
+		})
+	}
+}
+
+func TestKubeProvider_sharedClients(t *testing.T) {
+	built := map[string]int{}
+	var builtLock sync.Mutex
+	count := func(name string) {
+		builtLock.Lock()
+		defer builtLock.Unlock()
+		built[name]++
+	}
+
+	originalMain, originalAggregator, originalDynamic := newMainClientset, newAggregatorClientset, newDynamicClient
+	defer func() {
+		newMainClientset, newAggregatorClientset, newDynamicClient = originalMain, originalAggregator, originalDynamic
+	}()
+	newMainClientset = func(cfg *restclient.Config) (*kubernetes.Clientset, error) {
+		count("main")
+		return originalMain(cfg)
+	}
+	newAggregatorClientset = func(cfg *restclient.Config) (*aggregator.Clientset, error) {
+		count("aggregator")
+		return originalAggregator(cfg)
+	}
+	newDynamicClient = func(cfg *restclient.Config) (dynamic.Interface, error) {
+		count("dynamic")
+		return originalDynamic(cfg)
+	}
+
+	provider := newKubeProvider(&restclient.Config{Host: "https://example.com"})
+	clientsets := make(chan *kubernetes.Clientset, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clientset, err := provider.MainClientset()
+			if err != nil {
+				t.Error(err)
+			}
+			clientsets <- clientset
+			if _, err := provider.AggregatorClientset(); err != nil {
+				t.Error(err)
+			}
+			if _, err := provider.DynamicClient(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(clientsets)
+
+	first := <-clientsets
+	for clientset := range clientsets {
+		if clientset != first {
+			t.Errorf("MainClientset() returned different clientsets for the same provider")
+		}
+	}
+	for _, name := range []string{"main", "aggregator", "dynamic"} {
+		if built[name] != 1 {
+			t.Errorf("built %d %s clients, want 1 per provider", built[name], name)
+		}
+	}
+}
+
+func TestProvider_configureInCluster(t *testing.T) {
+	original := inClusterConfig
+	defer func() { inClusterConfig = original }()
+	inClusterConfig = func() (*restclient.Config, error) {
+		return &restclient.Config{Host: "https://10.0.0.1:443", BearerTokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token"}, nil
+	}
+
+	tests := []struct {
+		name  string
+		given map[string]interface{}
+		then  string
+	}{
+		{
+			"validate explicit in-cluster config",
+			map[string]interface{}{"in_cluster": true},
+			"https://10.0.0.1:443",
+		},
+		{
+			"validate fallback without a kube config",
+			map[string]interface{}{"config_path": "/nonexistent/kubeconfig"},
+			"https://10.0.0.1:443",
+		},
+		{
+			"validate host takes precedence over the fallback",
+			map[string]interface{}{"config_path": "/nonexistent/kubeconfig", "host": "https://example.com"},
+			"https://example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Provider()
+			if diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(tt.given)); diags.HasError() {
+				t.Fatalf("Configure() = %v", diags)
+			}
+			if host := p.Meta().(*KubeProvider).RestConfig.Host; host != tt.then {
+				t.Errorf("host = %q, want %q", host, tt.then)
+			}
+		})
+	}
+}
+
+func TestProvider_configureNamespace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "namespace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	originalConfig, originalFile := inClusterConfig, inClusterNamespaceFile
+	defer func() { inClusterConfig, inClusterNamespaceFile = originalConfig, originalFile }()
+	inClusterConfig = func() (*restclient.Config, error) {
+		return &restclient.Config{Host: "https://10.0.0.1:443"}, nil
+	}
+	inClusterNamespaceFile = filepath.Join(dir, "namespace")
+	if err := ioutil.WriteFile(inClusterNamespaceFile, []byte("runner\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := `
+apiVersion: v1
+kind: Config
+current-context: first
+clusters:
+- name: first
+  cluster:
+    server: https://first.example.com
+contexts:
+- name: first
+  context:
+    cluster: first
+    namespace: monitoring
+`
+
+	tests := []struct {
+		name  string
+		given map[string]interface{}
+		then  string
+	}{
+		{
+			"validate the kube config context namespace is used",
+			map[string]interface{}{"config_raw": raw},
+			"monitoring",
+		},
+		{
+			"validate namespace takes precedence over the kube config context",
+			map[string]interface{}{"config_raw": raw, "namespace": "apps"},
+			"apps",
+		},
+		{
+			"validate the service account namespace is used in-cluster",
+			map[string]interface{}{"in_cluster": true},
+			"runner",
+		},
+		{
+			"validate default without any namespace",
+			map[string]interface{}{"host": "https://example.com"},
+			"default",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Provider()
+			if diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(tt.given)); diags.HasError() {
+				t.Fatalf("Configure() = %v", diags)
+			}
+			if namespace := p.Meta().(*KubeProvider).namespace; namespace != tt.then {
+				t.Errorf("namespace = %q, want %q", namespace, tt.then)
+			}
+		})
+	}
+}
+
+func TestKubeProvider_forContext(t *testing.T) {
+	raw := `apiVersion: v1
+kind: Config
+current-context: first
+clusters:
+- name: first
+  cluster:
+    server: https://first.example.com
+- name: second
+  cluster:
+    server: https://second.example.com
+contexts:
+- name: first
+  context:
+    cluster: first
+- name: second
+  context:
+    cluster: second
+    namespace: monitoring
+`
+
+	p := Provider()
+	config := map[string]interface{}{"config_raw": raw, "qps": 5.0, "burst": 10, "read_retry_count": 3, "proxy_url": "socks5://localhost:1080"}
+	if diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(config)); diags.HasError() {
+		t.Fatalf("Configure() = %v", diags)
+	}
+	provider := p.Meta().(*KubeProvider)
+
+	second, err := provider.forContext("", "second", "", "")
+	if err != nil {
+		t.Fatalf("forContext() error = %v", err)
+	}
+	if again, _ := provider.forContext("", "second", "", ""); again != second {
+		t.Errorf("forContext() built a new provider for the same context, want it shared")
+	}
+	first, err := provider.forContext("", "first", "", "")
+	if err != nil {
+		t.Fatalf("forContext() error = %v", err)
+	}
+	if first == second {
+		t.Errorf("forContext() shared a provider between contexts, want one per context")
+	}
+
+	if second.RestConfig.Host != "https://second.example.com" || second.namespace != "monitoring" {
+		t.Errorf("forContext() = %s in namespace %q, want the cluster and namespace of the context from config_raw", second.RestConfig.Host, second.namespace)
+	}
+	if first.RestConfig.Host != "https://first.example.com" || first.namespace != "default" {
+		t.Errorf("forContext() = %s in namespace %q, want the cluster of the context in the default namespace", first.RestConfig.Host, first.namespace)
+	}
+	if second.RestConfig.QPS != 5 || second.RestConfig.Burst != 10 || second.RestConfig.RateLimiter == nil || second.readRetryCount != 3 {
+		t.Errorf("forContext() qps = %v, burst = %d, retries = %d, want the settings of the provider", second.RestConfig.QPS, second.RestConfig.Burst, second.readRetryCount)
+	}
+	if second.RestConfig.WrapTransport == nil || second.proxyURL != "socks5://localhost:1080" {
+		t.Fatalf("forContext() did not keep the proxy of the provider")
+	}
+	wrapped := second.RestConfig.WrapTransport(&http.Transport{})
+	transport, ok := wrapped.(*http.Transport)
+	if !ok {
+		t.Fatalf("forContext() transport = %T, want the proxied *http.Transport", wrapped)
+	}
+	if proxy, err := transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "second.example.com"}}); err != nil || proxy.String() != "socks5://localhost:1080" {
+		t.Errorf("forContext() transport proxy = %v, want proxy_url", proxy)
+	}
+}
+
+func TestProvider_configureToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		given     map[string]interface{}
+		thenToken string
+		thenFile  string
+	}{
+		{
+			"validate token",
+			map[string]interface{}{"host": "https://example.com", "token": "static"},
+			"static",
+			"",
+		},
+		{
+			"validate token_file",
+			map[string]interface{}{"host": "https://example.com", "token_file": "/var/run/secrets/token"},
+			"",
+			"/var/run/secrets/token",
+		},
+		{
+			"validate token_file takes precedence over token",
+			map[string]interface{}{"host": "https://example.com", "token": "static", "token_file": "/var/run/secrets/token"},
+			"",
+			"/var/run/secrets/token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Provider()
+			if diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(tt.given)); diags.HasError() {
+				t.Fatalf("Configure() = %v", diags)
+			}
+			cfg := p.Meta().(*KubeProvider).RestConfig
+			if cfg.BearerToken != tt.thenToken || cfg.BearerTokenFile != tt.thenFile {
+				t.Errorf("token = %q, token file = %q, want %q and %q", cfg.BearerToken, cfg.BearerTokenFile, tt.thenToken, tt.thenFile)
+			}
+		})
+	}
+}
+
+func TestProvider_configureRetryCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		given map[string]interface{}
+		then  uint64
+	}{
+		{"validate default", map[string]interface{}{}, 1},
+		{"validate read_retry_count", map[string]interface{}{"read_retry_count": 3}, 3},
+		{"validate the deprecated apply_retry_count", map[string]interface{}{"apply_retry_count": 2}, 2},
+		{"validate apply_retry_count disables retrying", map[string]interface{}{"apply_retry_count": 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.given["host"] = "https://example.com"
+			p := Provider()
+			if diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(tt.given)); diags.HasError() {
+				t.Fatalf("Configure() = %v", diags)
+			}
+			if count := p.Meta().(*KubeProvider).readRetryCount; count != tt.then {
+				t.Errorf("read retry count = %d, want %d", count, tt.then)
+			}
+		})
+	}
+
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"read_retry_count": 3, "apply_retry_count": 0})
+	if diags := Provider().Validate(config); !diags.HasError() {
+		t.Errorf("Validate() = %v, want read_retry_count to conflict with apply_retry_count", diags)
+	}
+}
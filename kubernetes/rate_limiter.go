@@ -0,0 +1,48 @@
+package kubernetes
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	rateLimiterTokenBucket = "token_bucket"
+	rateLimiterNone        = "none"
+)
+
+// throttleLogLatency is the delay after which client-side throttling of a request is logged.
+const throttleLogLatency = 50 * time.Millisecond
+
+// throttleLoggingRateLimiter is a token bucket rate limiter which logs when it delays a request,
+// as the client-go throttling messages never reach the Terraform logs.
+type throttleLoggingRateLimiter struct {
+	flowcontrol.RateLimiter
+}
+
+var _ flowcontrol.RateLimiter = &throttleLoggingRateLimiter{}
+
+func newThrottleLoggingRateLimiter(qps float32, burst int) flowcontrol.RateLimiter {
+	return &throttleLoggingRateLimiter{flowcontrol.NewTokenBucketRateLimiter(qps, burst)}
+}
+
+func (r *throttleLoggingRateLimiter) Accept() {
+	start := time.Now()
+	r.RateLimiter.Accept()
+	r.logThrottling(time.Since(start))
+}
+
+func (r *throttleLoggingRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := r.RateLimiter.Wait(ctx)
+	r.logThrottling(time.Since(start))
+	return err
+}
+
+func (r *throttleLoggingRateLimiter) logThrottling(latency time.Duration) {
+	if latency > throttleLogLatency {
+		log.Printf("[INFO] Client-side throttling delayed a request by %s (qps: %v), consider raising qps and burst", latency, r.QPS())
+	}
+}
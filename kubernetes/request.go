@@ -0,0 +1,107 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	restclient "k8s.io/client-go/rest"
+)
+
+const (
+	onForbiddenError = "error"
+	onForbiddenWarn  = "warn"
+	onForbiddenSkip  = "skip"
+)
+
+func namespacesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeList,
+		Optional:      true,
+		ForceNew:      true,
+		Elem:          &schema.Schema{Type: schema.TypeString},
+		ConflictsWith: []string{"namespace"},
+		Description:   "Namespaces to query, instead of the single namespace.",
+	}
+}
+
+func onForbiddenSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		ForceNew:     true,
+		Default:      onForbiddenError,
+		ValidateFunc: validation.StringInSlice([]string{onForbiddenError, onForbiddenWarn, onForbiddenSkip}, false),
+		Description:  "What to do when a namespace may not be read: error fails the read, warn returns the other namespaces with a warning and skip returns them silently.",
+	}
+}
+
+// namespacesFromResourceData returns the namespaces to query, the namespaces
+// list winning over the single namespace.
+func namespacesFromResourceData(d *schema.ResourceData, provider *KubeProvider) []string {
+	if v, ok := d.GetOk("namespaces"); ok {
+		return expandStringSlice(v.([]interface{}))
+	}
+	return []string{namespaceFromResourceData(d, provider)}
+}
+
+// listInNamespaces calls list for each namespace. Namespaces which may not be read
+// are handled according to onForbidden, so the read can return partial results
+// along with warnings naming the namespaces skipped.
+func (p *KubeProvider) listInNamespaces(ctx context.Context, resource string, namespaces []string, onForbidden string, list func(namespace string) error) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, namespace := range namespaces {
+		namespace := namespace
+		err := p.withReadRetry(ctx, fmt.Sprintf("list %s in namespace %s", resource, namespace), func() error {
+			return list(namespace)
+		})
+		if err == nil {
+			continue
+		}
+		if !apierrors.IsForbidden(err) || onForbidden == onForbiddenError {
+			return append(diags, diag.FromErr(err)...)
+		}
+
+		log.Printf("[WARN] Skipping %s in namespace %q: %s", resource, namespace, err)
+		if onForbidden == onForbiddenWarn {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Warning,
+				Summary:       fmt.Sprintf("Unable to list %s in namespace %q", resource, namespace),
+				Detail:        fmt.Sprintf("The %s of the namespace are missing from the results: %s", resource, err),
+				AttributePath: cty.GetAttrPath("on_forbidden"),
+			})
+		}
+	}
+	return diags
+}
+
+// listWithContext lists the resource in the namespace into list like the typed
+// clients do, which take no context in client-go v0.17, cancelling the request with ctx.
+func listWithContext(ctx context.Context, client restclient.Interface, namespace, resource string, opts v1.ListOptions, list runtime.Object) error {
+	return client.Get().
+		Namespace(namespace).
+		Resource(resource).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Context(ctx).
+		Do().
+		Into(list)
+}
+
+// createWithContext creates the cluster scoped resource from body into result like
+// the typed clients do, cancelling the request with ctx.
+func createWithContext(ctx context.Context, client restclient.Interface, resource string, body, result runtime.Object) error {
+	return client.Post().
+		Resource(resource).
+		Body(body).
+		Context(ctx).
+		Do().
+		Into(result)
+}
@@ -0,0 +1,57 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestKubeProvider_listInNamespaces(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+
+	tests := []struct {
+		name         string
+		onForbidden  string
+		thenListed   []string
+		thenError    bool
+		thenWarnings int
+	}{
+		{"validate error fails the read", onForbiddenError, []string{"apps"}, true, 0},
+		{"validate warn skips with a warning", onForbiddenWarn, []string{"apps", "web"}, false, 1},
+		{"validate skip skips silently", onForbiddenSkip, []string{"apps", "web"}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &KubeProvider{}
+			listed := []string{}
+			diags := provider.listInNamespaces(context.Background(), "pods", []string{"apps", "kube-system", "web"}, tt.onForbidden, func(namespace string) error {
+				if namespace == "kube-system" {
+					return apierrors.NewForbidden(pods, "", fmt.Errorf("denied"))
+				}
+				listed = append(listed, namespace)
+				return nil
+			})
+
+			if diags.HasError() != tt.thenError {
+				t.Errorf("listInNamespaces() = %v, want error %v", diags, tt.thenError)
+			}
+			warnings := 0
+			for _, d := range diags {
+				if d.Severity == diag.Warning {
+					warnings++
+				}
+			}
+			if warnings != tt.thenWarnings {
+				t.Errorf("listInNamespaces() = %v, want %d warnings", diags, tt.thenWarnings)
+			}
+			if !reflect.DeepEqual(listed, tt.thenListed) {
+				t.Errorf("listInNamespaces() listed %v, want %v", listed, tt.thenListed)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceKubectlAccessReview() *schema.Resource {
+	resourceSchema := dataSourceKubectlAccessReviewSchema()
+	resourceSchema["triggers"] = &schema.Schema{
+		Type:     schema.TypeMap,
+		Optional: true,
+		ForceNew: true,
+	}
+	for _, k := range []string{"user", "groups", "fail_if_denied", "check", "rules_namespace"} {
+		resourceSchema[k].ForceNew = true
+	}
+
+	return &schema.Resource{
+		CreateContext: createWithReadTimeout(dataSourceKubectlAccessReviewRead),
+		ReadContext:   dataSourceKubectlAccessReviewRead,
+		DeleteContext: resourceKubectlAccessReviewDelete,
+		Schema:        resourceSchema,
+		Timeouts:      queryResourceTimeouts(),
+	}
+}
+
+func resourceKubectlAccessReviewDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,33 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceKubectlHTTPProbe() *schema.Resource {
+	resourceSchema := dataSourceKubectlHTTPProbeSchema()
+	resourceSchema["triggers"] = &schema.Schema{
+		Type:     schema.TypeMap,
+		Optional: true,
+		ForceNew: true,
+	}
+	for _, k := range []string{"service", "pod", "port", "scheme", "path", "query", "request_headers", "max_body_size"} {
+		resourceSchema[k].ForceNew = true
+	}
+
+	return &schema.Resource{
+		CreateContext: createWithReadTimeout(dataSourceKubectlHTTPProbeRead),
+		ReadContext:   dataSourceKubectlHTTPProbeRead,
+		DeleteContext: resourceKubectlHTTPProbeDelete,
+		Schema:        resourceSchema,
+		Timeouts:      queryResourceTimeouts(),
+	}
+}
+
+func resourceKubectlHTTPProbeDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,151 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func resourceKubectlPodExec() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: createWithReadTimeout(resourceKubectlPodExecCreate),
+		ReadContext:   resourceKubectlPodExecRead,
+		DeleteContext: resourceKubectlPodExecDelete,
+		Schema: map[string]*schema.Schema{
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+			},
+			"kubeconfig":          kubeconfigOverrideSchema(),
+			"namespace":           namespaceSchema(),
+			"effective_namespace": effectiveNamespaceSchema(),
+			"pod": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"pod", "label_selector"},
+				Description:  "Name of the pod to run the command in.",
+			},
+			"label_selector": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Label selector of the pod to run the command in, e.g. app.kubernetes.io/name=vault. Running pods are preferred when several match, then the newest.",
+			},
+			"container": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Container to run the command in, required when the pod has several.",
+			},
+			"command": &schema.Schema{
+				Type:        schema.TypeList,
+				Required:    true,
+				ForceNew:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Command to run, which is not run in a shell, e.g. [\"sh\", \"-c\", \"cat /vault/token\"].",
+			},
+			"stdin": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Sensitive:   true,
+				Description: "Input streamed to the command.",
+			},
+			"fail_if_nonzero_exit": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     true,
+				Description: "Fail when the command exits with a non-zero code, rather than only recording the code.",
+			},
+			"pod_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"stdout": &schema.Schema{
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"stderr": &schema.Schema{
+				Type:      schema.TypeString,
+				Computed:  true,
+				Sensitive: true,
+			},
+			"exit_code": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+		Timeouts: queryResourceTimeouts(),
+	}
+}
+
+func resourceKubectlPodExecCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := kubeProviderFromResourceData(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if skip, err := provider.skipReadForUnknownConfig(d); skip {
+		return diag.FromErr(err)
+	}
+	client, err := provider.MainClientset()
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	namespace := namespaceFromResourceData(d, provider)
+	var pod *corev1.Pod
+	err = provider.withReadRetry(ctx, "select pod", func() (err error) {
+		pod, err = selectPod(ctx, client.CoreV1().RESTClient(), namespace, d.Get("pod").(string), d.Get("label_selector").(string))
+		return err
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// The command is not retried, as it may not be safe to run twice
+	command := expandStringSlice(d.Get("command").([]interface{}))
+	var stdin io.Reader
+	if v, ok := d.GetOk("stdin"); ok {
+		stdin = strings.NewReader(v.(string))
+	}
+	var stdout, stderr bytes.Buffer
+	exitCode, err := provider.execInPod(ctx, client.CoreV1().RESTClient(), namespace, pod.Name, d.Get("container").(string), command, stdin, &stdout, &stderr)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if exitCode != 0 && d.Get("fail_if_nonzero_exit").(bool) {
+		return diag.Errorf("%q exited with code %d in pod %s/%s: %s", strings.Join(command, " "), exitCode, namespace, pod.Name, stderr.String())
+	}
+
+	_ = d.Set("effective_namespace", namespace)
+	_ = d.Set("pod_name", pod.Name)
+	_ = d.Set("stdout", stdout.String())
+	_ = d.Set("stderr", stderr.String())
+	_ = d.Set("exit_code", exitCode)
+
+	d.SetId(fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s", namespace, pod.Name, strings.Join(command, "\x00"))))))
+	return nil
+}
+
+// resourceKubectlPodExecRead keeps the output of the command, which is only run
+// again when the resource is replaced, e.g. because its triggers changed.
+func resourceKubectlPodExecRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return nil
+}
+
+func resourceKubectlPodExecDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,33 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceKubectlPodFile() *schema.Resource {
+	resourceSchema := dataSourceKubectlPodFileSchema()
+	resourceSchema["triggers"] = &schema.Schema{
+		Type:     schema.TypeMap,
+		Optional: true,
+		ForceNew: true,
+	}
+	for _, k := range []string{"pod", "label_selector", "container", "path", "archive", "max_size"} {
+		resourceSchema[k].ForceNew = true
+	}
+
+	return &schema.Resource{
+		CreateContext: createWithReadTimeout(dataSourceKubectlPodFileRead),
+		ReadContext:   dataSourceKubectlPodFileRead,
+		DeleteContext: resourceKubectlPodFileDelete,
+		Schema:        resourceSchema,
+		Timeouts:      queryResourceTimeouts(),
+	}
+}
+
+func resourceKubectlPodFileDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,33 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceKubectlPodLogs() *schema.Resource {
+	resourceSchema := dataSourceKubectlPodLogsSchema()
+	resourceSchema["triggers"] = &schema.Schema{
+		Type:     schema.TypeMap,
+		Optional: true,
+		ForceNew: true,
+	}
+	for _, k := range []string{"pod", "label_selector", "container", "previous", "tail_lines", "limit_bytes", "since", "since_time"} {
+		resourceSchema[k].ForceNew = true
+	}
+
+	return &schema.Resource{
+		CreateContext: createWithReadTimeout(dataSourceKubectlPodLogsRead),
+		ReadContext:   dataSourceKubectlPodLogsRead,
+		DeleteContext: resourceKubectlPodLogsDelete,
+		Schema:        resourceSchema,
+		Timeouts:      queryResourceTimeouts(),
+	}
+}
+
+func resourceKubectlPodLogsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
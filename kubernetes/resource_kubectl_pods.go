@@ -1,29 +1,41 @@
 package kubernetes
 
 import (
+	"context"
 	"strconv"
 	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 )
 
-func dataSourceKubectlPodsRead(d *schema.ResourceData, meta interface{}) error {
-	provider := meta.(*KubeProvider)
-	clientConfig, err := provider.ToRESTConfig()
+func dataSourceKubectlPodsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := kubeProviderFromResourceData(d, meta)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-
-	client, err := kubernetes.NewForConfig(clientConfig)
+	if skip, err := provider.skipReadForUnknownConfig(d); skip {
+		return diag.FromErr(err)
+	}
+	client, err := provider.MainClientset()
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	pods, err := client.CoreV1().Pods("default").List(v1.ListOptions{})
-	if err != nil {
-		return err
+	namespaces := namespacesFromResourceData(d, provider)
+	pods := &corev1.PodList{}
+	diags := provider.listInNamespaces(ctx, "pods", namespaces, d.Get("on_forbidden").(string), func(namespace string) error {
+		list := &corev1.PodList{}
+		if err := listWithContext(ctx, client.CoreV1().RESTClient(), namespace, "pods", v1.ListOptions{}, list); err != nil {
+			return err
+		}
+		pods.Items = append(pods.Items, list.Items...)
+		return nil
+	})
+	if diags.HasError() {
+		return diags
 	}
 
 	properties := map[string]interface{}{}
@@ -33,6 +45,7 @@ func dataSourceKubectlPodsRead(d *schema.ResourceData, meta interface{}) error {
 			"kind":            pod.Kind,
 			"status":          pod.Status.String(),
 			"labels":          pod.Labels,
+			"namespace":       pod.Namespace,
 			"cluster_name":     pod.ClusterName,
 			"generate_name":    pod.GenerateName,
 			"resource_version": pod.ResourceVersion,
@@ -42,17 +55,20 @@ func dataSourceKubectlPodsRead(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	properties["pods"] = pods_list
+	if len(namespaces) == 1 {
+		properties["effective_namespace"] = namespaces[0]
+	}
 
 	for k, v := range properties {
 		err := d.Set(k, v)
 		if err != nil {
-			return err
+			return diag.FromErr(err)
 		}
 	}
 
 	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
 
-	return nil
+	return diags
 }
 
 func dataSourceKubectlPodsSchema() map[string]*schema.Schema {
@@ -62,6 +78,11 @@ func dataSourceKubectlPodsSchema() map[string]*schema.Schema {
 			Optional: true,
 			ForceNew: true,
 		},
+		"kubeconfig":          kubeconfigOverrideSchema(),
+		"namespace":           namespaceSchema(),
+		"effective_namespace": effectiveNamespaceSchema(),
+		"namespaces":          namespacesSchema(),
+		"on_forbidden":        onForbiddenSchema(),
 		"pods": &schema.Schema{
 			Type:     schema.TypeList,
 			Computed: true,
@@ -79,6 +100,10 @@ func dataSourceKubectlPodsSchema() map[string]*schema.Schema {
 						Type:     schema.TypeMap,
 						Computed: true,
 					},
+					"namespace": &schema.Schema{
+						Type:     schema.TypeString,
+						Computed: true,
+					},
 					"cluster_name": &schema.Schema{
 						Type:     schema.TypeString,
 						Computed: true,
@@ -107,21 +132,25 @@ func dataSourceKubectlPodsSchema() map[string]*schema.Schema {
 
 func resourceKubectlPods() *schema.Resource {
 	return &schema.Resource{
-		Create: dataSourceKubectlPodsRead,
-		Read:   dataSourceKubectlPodsRead,
-		Delete: dataSourceKubectlPodsDelete,
-		Schema: dataSourceKubectlPodsSchema(),
+		CreateContext: createWithReadTimeout(dataSourceKubectlPodsRead),
+		ReadContext:   dataSourceKubectlPodsRead,
+		DeleteContext: dataSourceKubectlPodsDelete,
+		Schema:        dataSourceKubectlPodsSchema(),
+		Timeouts:      queryResourceTimeouts(),
 	}
 }
 
 func dataSourceKubectlPods() *schema.Resource {
+	dataSourceSchema := dataSourceKubectlPodsSchema()
+	dataSourceSchema["timeouts"] = dataSourceTimeoutsSchema()
+
 	return &schema.Resource{
-		Read:   dataSourceKubectlPodsRead,
-		Schema: dataSourceKubectlPodsSchema(),
+		ReadContext: readWithDataSourceTimeout(dataSourceKubectlPodsRead),
+		Schema:      dataSourceSchema,
 	}
 }
 
-func dataSourceKubectlPodsDelete(d *schema.ResourceData, meta interface{}) error {
+func dataSourceKubectlPodsDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	d.SetId("")
 	return nil
 }
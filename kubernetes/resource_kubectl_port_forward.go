@@ -0,0 +1,217 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// resourceKubectlPortForward forwards a local port to a pod for as long as the
+// provider process runs. Terraform starts a provider process for each plan and
+// apply, so the port-forward is planned as an update in every run for the apply to
+// start it again, see the description.
+func resourceKubectlPortForward() *schema.Resource {
+	return &schema.Resource{
+		Description: "Forwards a local port to a pod like kubectl port-forward, for providers such as postgresql or vault to reach services in the cluster. " +
+			"The port-forward runs for as long as the provider process: refreshing starts it for the plan, and every plan updates the resource, " +
+			"showing pod_name as known after apply, so that the apply starts it again on the same local port. " +
+			"Terraform closes a provider once its own resources are done, so consumers in other providers need a resource of this provider " +
+			"to depend on them to keep the port-forward open until they are done too.",
+		CreateContext: createWithReadTimeout(resourceKubectlPortForwardCreate),
+		ReadContext:   resourceKubectlPortForwardRead,
+		UpdateContext: resourceKubectlPortForwardUpdate,
+		DeleteContext: resourceKubectlPortForwardDelete,
+		CustomizeDiff: resourceKubectlPortForwardCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+			},
+			"kubeconfig":          kubeconfigOverrideSchema(),
+			"namespace":           namespaceSchema(),
+			"effective_namespace": effectiveNamespaceSchema(),
+			"pod": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"pod", "service", "label_selector"},
+				Description:  "Name of the pod to forward to.",
+			},
+			"service": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the service to forward to, through a pod it selects like kubectl port-forward.",
+			},
+			"label_selector": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Label selector of the pod to forward to. Running pods are preferred when several match, then the newest.",
+			},
+			"remote_port": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Number or name of the port to forward to, a service port for services. Defaults to the first port of the service, required for pods.",
+			},
+			"local_host": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "127.0.0.1",
+				ValidateFunc: validation.Any(
+					validation.IsIPAddress,
+					validation.StringInSlice([]string{"localhost"}, false),
+				),
+				Description: "Address to listen on.",
+			},
+			"local_port": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IsPortNumberOrZero,
+				Description:  "Port to listen on, a free port when unset. The port of the previous run is reused while it is free.",
+			},
+			"pod_name": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+		Timeouts: queryResourceTimeouts(),
+	}
+}
+
+func resourceKubectlPortForwardCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	id := resource.UniqueId()
+	if err := resourceKubectlPortForwardStart(ctx, d, meta, id, d.Get("local_port").(int)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(id)
+	return nil
+}
+
+// resourceKubectlPortForwardRead starts the port-forward again in new provider
+// processes, i.e. in each run, on the port of the previous run while it is free.
+// A target which can't be forwarded to fails the apply rather than the refresh,
+// which would otherwise keep the resource from being planned or destroyed.
+func resourceKubectlPortForwardRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	portForwardsLock.Lock()
+	forward, ok := portForwards[d.Id()]
+	portForwardsLock.Unlock()
+	if ok {
+		_ = d.Set("local_port", forward.localPort)
+		_ = d.Set("pod_name", forward.podName)
+		return nil
+	}
+
+	localPort := d.Get("local_port").(int)
+	if listener, err := net.Listen("tcp", net.JoinHostPort(d.Get("local_host").(string), fmt.Sprint(localPort))); err == nil {
+		_ = listener.Close()
+	} else {
+		log.Printf("[INFO] Port %d of the previous port-forward is not free, forwarding a free port instead: %v", localPort, err)
+		localPort = 0
+	}
+	err := resourceKubectlPortForwardStart(ctx, d, meta, d.Id(), localPort)
+	if apierrors.IsNotFound(err) {
+		log.Printf("[WARN] The target of port-forward %s is gone, removing it from the state: %v", d.Id(), err)
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		log.Printf("[WARN] Unable to start port-forward %s while refreshing, keeping its last state: %v", d.Id(), err)
+	}
+	return nil
+}
+
+// resourceKubectlPortForwardUpdate starts the port-forward in the provider process
+// of the apply, planned by resourceKubectlPortForwardCustomizeDiff in every run.
+func resourceKubectlPortForwardUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	portForwardsLock.Lock()
+	forward, ok := portForwards[d.Id()]
+	if ok && (forward.localHost != d.Get("local_host").(string) || forward.localPort != d.Get("local_port").(int)) {
+		forward.stop()
+		delete(portForwards, d.Id())
+		ok = false
+	}
+	portForwardsLock.Unlock()
+	if ok {
+		_ = d.Set("pod_name", forward.podName)
+		return nil
+	}
+
+	if err := resourceKubectlPortForwardStart(ctx, d, meta, d.Id(), d.Get("local_port").(int)); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+// resourceKubectlPortForwardCustomizeDiff plans an update in every run, as the
+// apply runs in a new provider process which only starts the port-forward when
+// the resource is created or updated.
+func resourceKubectlPortForwardCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	return d.SetNewComputed("pod_name")
+}
+
+func resourceKubectlPortForwardStart(ctx context.Context, d *schema.ResourceData, meta interface{}, id string, localPort int) error {
+	provider, err := kubeProviderFromResourceData(d, meta)
+	if err != nil {
+		return err
+	}
+	if skip, err := provider.skipReadForUnknownConfig(d); skip {
+		return err
+	}
+	client, err := provider.MainClientset()
+	if err != nil {
+		return err
+	}
+
+	target := portForwardTarget{
+		namespace:     namespaceFromResourceData(d, provider),
+		pod:           d.Get("pod").(string),
+		service:       d.Get("service").(string),
+		labelSelector: d.Get("label_selector").(string),
+		remotePort:    d.Get("remote_port").(string),
+	}
+	if target.service == "" && target.remotePort == "" {
+		return fmt.Errorf("remote_port is required to forward to a pod")
+	}
+
+	// The port-forward outlives the request, ending when Terraform stops the provider
+	var forward *portForward
+	err = provider.withReadRetry(ctx, "start port-forward", func() (err error) {
+		forward, err = provider.startPortForward(ctx, provider.stopContext(), client.CoreV1().RESTClient(), target, d.Get("local_host").(string), localPort)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	portForwardsLock.Lock()
+	portForwards[id] = forward
+	portForwardsLock.Unlock()
+
+	_ = d.Set("effective_namespace", target.namespace)
+	_ = d.Set("local_port", forward.localPort)
+	_ = d.Set("pod_name", forward.podName)
+	return nil
+}
+
+func resourceKubectlPortForwardDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	portForwardsLock.Lock()
+	if forward, ok := portForwards[d.Id()]; ok {
+		forward.stop()
+		delete(portForwards, d.Id())
+	}
+	portForwardsLock.Unlock()
+
+	d.SetId("")
+	return nil
+}
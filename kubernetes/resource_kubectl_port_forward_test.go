@@ -0,0 +1,80 @@
+package kubernetes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	restclient "k8s.io/client-go/rest"
+)
+
+func Test_resourceKubectlPortForwardRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/db/services/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := newKubeProvider(&restclient.Config{Host: server.URL})
+	provider.namespace = "db"
+
+	tests := []struct {
+		name    string
+		service string
+		thenID  string
+	}{
+		{"validate a deleted target is removed from the state", "postgres", ""},
+		{"validate other failures keep the last state", "forbidden", "forward"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := schema.TestResourceDataRaw(t, resourceKubectlPortForward().Schema, map[string]interface{}{"service": tt.service})
+			d.SetId("forward")
+			if diags := resourceKubectlPortForwardRead(context.Background(), d, provider); diags.HasError() {
+				t.Fatalf("resourceKubectlPortForwardRead() = %v, want the refresh to succeed", diags)
+			}
+			if d.Id() != tt.thenID {
+				t.Errorf("resourceKubectlPortForwardRead() id = %q, want %q", d.Id(), tt.thenID)
+			}
+		})
+	}
+}
+
+func Test_resourceKubectlPortForwardCreate(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	provider := newKubeProvider(&restclient.Config{Host: server.URL})
+
+	d := schema.TestResourceDataRaw(t, resourceKubectlPortForward().Schema, map[string]interface{}{"service": "postgres"})
+	if diags := resourceKubectlPortForwardCreate(context.Background(), d, provider); !diags.HasError() || d.Id() != "" {
+		t.Errorf("resourceKubectlPortForwardCreate() = %v with id %q, want an error without id", diags, d.Id())
+	}
+}
+
+func Test_resourceKubectlPortForwardCustomizeDiff(t *testing.T) {
+	state := &terraform.InstanceState{
+		ID: "forward",
+		Attributes: map[string]string{
+			"id":         "forward",
+			"service":    "postgres",
+			"local_host": "127.0.0.1",
+			"local_port": "15432",
+			"pod_name":   "postgres-0",
+		},
+	}
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{"service": "postgres"})
+	diff, err := resourceKubectlPortForward().SimpleDiff(context.Background(), state, config, nil)
+	if err != nil {
+		t.Fatalf("SimpleDiff() error = %v", err)
+	}
+	if diff == nil || !diff.Attributes["pod_name"].NewComputed || diff.RequiresNew() {
+		t.Errorf("SimpleDiff() = %v, want an update computing pod_name again", diff)
+	}
+}
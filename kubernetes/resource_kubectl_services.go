@@ -1,30 +1,42 @@
 package kubernetes
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 
 	"github.com/ghodss/yaml"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 )
 
-func dataSourceKubectlServicesRead(d *schema.ResourceData, meta interface{}) error {
-	provider := meta.(*KubeProvider)
-	clientConfig, err := provider.ToRESTConfig()
+func dataSourceKubectlServicesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	provider, err := kubeProviderFromResourceData(d, meta)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-
-	client, err := kubernetes.NewForConfig(clientConfig)
+	if skip, err := provider.skipReadForUnknownConfig(d); skip {
+		return diag.FromErr(err)
+	}
+	client, err := provider.MainClientset()
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	services, err := client.CoreV1().Services("default").List(v1.ListOptions{})
-	if err != nil {
-		return err
+	namespaces := namespacesFromResourceData(d, provider)
+	services := &corev1.ServiceList{}
+	diags := provider.listInNamespaces(ctx, "services", namespaces, d.Get("on_forbidden").(string), func(namespace string) error {
+		list := &corev1.ServiceList{}
+		if err := listWithContext(ctx, client.CoreV1().RESTClient(), namespace, "services", v1.ListOptions{}, list); err != nil {
+			return err
+		}
+		services.Items = append(services.Items, list.Items...)
+		return nil
+	})
+	if diags.HasError() {
+		return diags
 	}
 
 	properties := map[string]interface{}{}
@@ -35,6 +47,7 @@ func dataSourceKubectlServicesRead(d *schema.ResourceData, meta interface{}) err
 			"kind":            service.Kind,
 			"status":          service.Status.String(),
 			"labels":          service.Labels,
+			"namespace":       service.Namespace,
 			"cluster_name":     service.ClusterName,
 			"generate_name":    service.GenerateName,
 			"resource_version": service.ResourceVersion,
@@ -83,21 +96,24 @@ func dataSourceKubectlServicesRead(d *schema.ResourceData, meta interface{}) err
 	}
 
 	properties["services"] = servicesList
+	if len(namespaces) == 1 {
+		properties["effective_namespace"] = namespaces[0]
+	}
 
 	for k, v := range properties {
 		err := d.Set(k, v)
 		if err != nil {
-			return err
+			return diag.FromErr(err)
 		}
 	}
 
 	props, err := yaml.Marshal(properties)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.SetId(fmt.Sprintf("%x", sha256.Sum256(props)))
 
-	return nil
+	return diags
 }
 
 func dataSourceKubectlServicesSchema() map[string]*schema.Schema {
@@ -107,6 +123,11 @@ func dataSourceKubectlServicesSchema() map[string]*schema.Schema {
 			Optional: true,
 			ForceNew: true,
 		},
+		"kubeconfig":          kubeconfigOverrideSchema(),
+		"namespace":           namespaceSchema(),
+		"effective_namespace": effectiveNamespaceSchema(),
+		"namespaces":          namespacesSchema(),
+		"on_forbidden":        onForbiddenSchema(),
 		"services": &schema.Schema{
 			Type:     schema.TypeList,
 			Computed: true,
@@ -128,6 +149,10 @@ func dataSourceKubectlServicesSchema() map[string]*schema.Schema {
 						Type:     schema.TypeMap,
 						Computed: true,
 					},
+					"namespace": &schema.Schema{
+						Type:     schema.TypeString,
+						Computed: true,
+					},
 					"cluster_name": &schema.Schema{
 						Type:     schema.TypeString,
 						Computed: true,
@@ -223,21 +248,25 @@ func dataSourceKubectlServicesSchema() map[string]*schema.Schema {
 
 func resourceKubectlServices() *schema.Resource {
 	return &schema.Resource{
-		Create: dataSourceKubectlServicesRead,
-		Read:   dataSourceKubectlServicesRead,
-		Delete: dataSourceKubectlServicesDelete,
-		Schema: dataSourceKubectlServicesSchema(),
+		CreateContext: createWithReadTimeout(dataSourceKubectlServicesRead),
+		ReadContext:   dataSourceKubectlServicesRead,
+		DeleteContext: dataSourceKubectlServicesDelete,
+		Schema:        dataSourceKubectlServicesSchema(),
+		Timeouts:      queryResourceTimeouts(),
 	}
 }
 
 func dataSourceKubectlServices() *schema.Resource {
+	dataSourceSchema := dataSourceKubectlServicesSchema()
+	dataSourceSchema["timeouts"] = dataSourceTimeoutsSchema()
+
 	return &schema.Resource{
-		Read:   dataSourceKubectlServicesRead,
-		Schema: dataSourceKubectlServicesSchema(),
+		ReadContext: readWithDataSourceTimeout(dataSourceKubectlServicesRead),
+		Schema:      dataSourceSchema,
 	}
 }
 
-func dataSourceKubectlServicesDelete(d *schema.ResourceData, meta interface{}) error {
+func dataSourceKubectlServicesDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	d.SetId("")
 	return nil
 }
@@ -0,0 +1,30 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceKubectlWhoami() *schema.Resource {
+	resourceSchema := dataSourceKubectlWhoamiSchema()
+	resourceSchema["triggers"] = &schema.Schema{
+		Type:     schema.TypeMap,
+		Optional: true,
+		ForceNew: true,
+	}
+
+	return &schema.Resource{
+		CreateContext: createWithReadTimeout(dataSourceKubectlWhoamiRead),
+		ReadContext:   dataSourceKubectlWhoamiRead,
+		DeleteContext: resourceKubectlWhoamiDelete,
+		Schema:        resourceSchema,
+		Timeouts:      queryResourceTimeouts(),
+	}
+}
+
+func resourceKubectlWhoamiDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
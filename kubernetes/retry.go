@@ -0,0 +1,51 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// withReadRetry runs the read, retrying it with exponential backoff up to the
+// configured read_retry_count while it fails with a transient error. Retrying
+// stops once ctx is done.
+func (p *KubeProvider) withReadRetry(ctx context.Context, description string, read func() error) error {
+	operation := func() error {
+		err := read()
+		if err != nil && !isRetryableError(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+	notify := func(err error, next time.Duration) {
+		log.Printf("[WARN] Failed to %s, retrying in %s: %s", description, next, err)
+	}
+
+	policy := backoff.WithContext(backoff.WithMaxRetries(backoff.NewExponentialBackOff(), p.readRetryCount), ctx)
+	return backoff.RetryNotify(operation, policy, notify)
+}
+
+// isRetryableError reports whether err, or any error it wraps, is transient: a
+// timeout, throttling, a server side failure or a refused connection. Client
+// errors such as 403 or 404 will not go away by retrying.
+func isRetryableError(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if status, ok := err.(apierrors.APIStatus); ok {
+			code := status.Status().Code
+			return code == 429 || code >= 500 || apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err)
+		}
+		if utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err) {
+			return true
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return true
+		}
+	}
+	return false
+}
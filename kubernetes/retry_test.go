@@ -0,0 +1,83 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_isRetryableError(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	connectionRefused := &url.Error{Op: "Get", URL: "https://localhost", Err: &net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}}
+
+	tests := []struct {
+		name  string
+		given error
+		then  bool
+	}{
+		{"validate too many requests is retried", apierrors.NewTooManyRequests("slow down", 1), true},
+		{"validate internal error is retried", apierrors.NewInternalError(fmt.Errorf("boom")), true},
+		{"validate service unavailable is retried", apierrors.NewServiceUnavailable("unavailable"), true},
+		{"validate server timeout is retried", apierrors.NewServerTimeout(pods, "list", 1), true},
+		{"validate connection refused is retried", connectionRefused, true},
+		{"validate wrapped errors are retried", fmt.Errorf("failed to list: %w", connectionRefused), true},
+		{"validate forbidden is not retried", apierrors.NewForbidden(pods, "", fmt.Errorf("denied")), false},
+		{"validate not found is not retried", apierrors.NewNotFound(pods, "missing"), false},
+		{"validate unknown errors are not retried", fmt.Errorf("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.given); got != tt.then {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.given, got, tt.then)
+			}
+		})
+	}
+}
+
+func TestKubeProvider_withReadRetry(t *testing.T) {
+	provider := &KubeProvider{readRetryCount: 1}
+	pods := schema.GroupResource{Resource: "pods"}
+
+	attempts := 0
+	err := provider.withReadRetry(context.Background(), "list pods", func() error {
+		attempts++
+		if attempts == 1 {
+			return apierrors.NewServiceUnavailable("unavailable")
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Errorf("withReadRetry() = %v after %d attempts, want success after 2 attempts", err, attempts)
+	}
+
+	attempts = 0
+	err = provider.withReadRetry(context.Background(), "list pods", func() error {
+		attempts++
+		return apierrors.NewForbidden(pods, "", fmt.Errorf("denied"))
+	})
+	if !apierrors.IsForbidden(err) || attempts != 1 {
+		t.Errorf("withReadRetry() = %v after %d attempts, want forbidden after 1 attempt", err, attempts)
+	}
+}
+
+func TestKubeProvider_withReadRetryCancelled(t *testing.T) {
+	provider := &KubeProvider{readRetryCount: 5}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	err := provider.withReadRetry(ctx, "list pods", func() error {
+		attempts++
+		return apierrors.NewServiceUnavailable("unavailable")
+	})
+	if !apierrors.IsServiceUnavailable(err) || attempts != 1 {
+		t.Errorf("withReadRetry() = %v after %d attempts, want no retry once the context is done", err, attempts)
+	}
+}
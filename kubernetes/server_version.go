@@ -0,0 +1,169 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+)
+
+// serverDistributionHints maps markers found in the server git version to the
+// distribution they identify, e.g. v1.27.4-eks-2d98532 or v1.20.0+k3s2.
+var serverDistributionHints = []struct {
+	marker       string
+	distribution string
+}{
+	{"-eks-", "eks"},
+	{"-gke.", "gke"},
+	{"+k3s", "k3s"},
+	{"+rke2", "rke2"},
+}
+
+// serverVersion is the parsed form of the version reported by the API server.
+type serverVersion struct {
+	Major         int
+	Minor         int
+	Patch         int
+	Prerelease    string
+	BuildMetadata string
+	Distribution  string
+}
+
+// parseServerVersion parses the server git version (v1.27.4-eks-2d98532,
+// v1.20.0+k3s2, ...) as semver. When the git version is unusable it falls back
+// to the major/minor fields, which some vendors suffix with "+" (e.g. "27+").
+func parseServerVersion(info *k8sversion.Info) (*serverVersion, error) {
+	if parsed, err := goversion.NewSemver(info.GitVersion); err == nil {
+		segments := parsed.Segments()
+		return &serverVersion{
+			Major:         segments[0],
+			Minor:         segments[1],
+			Patch:         segments[2],
+			Prerelease:    parsed.Prerelease(),
+			BuildMetadata: parsed.Metadata(),
+			Distribution:  serverDistribution(info.GitVersion),
+		}, nil
+	}
+
+	major, err := strconv.Atoi(strings.TrimSuffix(info.Major, "+"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server version %q: invalid major version %q", info.GitVersion, info.Major)
+	}
+	minor, err := strconv.Atoi(strings.TrimSuffix(info.Minor, "+"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server version %q: invalid minor version %q", info.GitVersion, info.Minor)
+	}
+	return &serverVersion{
+		Major:        major,
+		Minor:        minor,
+		Distribution: serverDistribution(info.GitVersion),
+	}, nil
+}
+
+// String returns the major.minor.patch core of the version, prefixed with "v".
+func (v *serverVersion) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Satisfies checks the constraint against the major.minor.patch core of the
+// version only. Vendors put their build identifiers in the prerelease part
+// (v1.27.4-eks-2d98532), which would otherwise never match a plain constraint.
+func (v *serverVersion) Satisfies(constraint string) (bool, error) {
+	constraints, err := goversion.NewConstraint(constraint)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse version constraint %q: %s", constraint, err)
+	}
+
+	core, err := goversion.NewVersion(v.String())
+	if err != nil {
+		return false, err
+	}
+	return constraints.Check(core), nil
+}
+
+func serverDistribution(gitVersion string) string {
+	for _, hint := range serverDistributionHints {
+		if strings.Contains(gitVersion, hint.marker) {
+			return hint.distribution
+		}
+	}
+	return ""
+}
+
+// commonlyBranchedAPIs lists the API versions modules usually switch on when a
+// resource graduates, keyed by the name exported in supported_apis.
+var commonlyBranchedAPIs = []struct {
+	name         string
+	groupVersion string
+	kind         string
+}{
+	{"networking.k8s.io/v1/Ingress", "networking.k8s.io/v1", "Ingress"},
+	{"batch/v1/CronJob", "batch/v1", "CronJob"},
+	{"policy/v1/PodDisruptionBudget", "policy/v1", "PodDisruptionBudget"},
+	{"autoscaling/v2/HorizontalPodAutoscaler", "autoscaling/v2", "HorizontalPodAutoscaler"},
+}
+
+// serverVersionWithContext gets the version of the server like the discovery
+// client does, cancelling the request with ctx.
+func serverVersionWithContext(ctx context.Context, discoveryClient discovery.DiscoveryInterface) (*k8sversion.Info, error) {
+	body, err := discoveryClient.RESTClient().Get().AbsPath("/version").Context(ctx).Do().Raw()
+	if err != nil {
+		return nil, err
+	}
+	var info k8sversion.Info
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("unable to parse the server version: %v", err)
+	}
+	return &info, nil
+}
+
+// discoverSupportedAPIs asks the discovery endpoint which of the commonly
+// branched APIs are served, rather than deriving it from the server version.
+// The discovery client takes no context, so ctx is checked between lookups.
+func discoverSupportedAPIs(ctx context.Context, discoveryClient discovery.CachedDiscoveryInterface) (map[string]bool, error) {
+	supported := map[string]bool{}
+	for _, api := range commonlyBranchedAPIs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		served, err := discoverResourceKind(discoveryClient, api.groupVersion, api.kind)
+		if err != nil {
+			return nil, err
+		}
+		supported[api.name] = served
+	}
+	return supported, nil
+}
+
+// discoverResourceKind reports whether the kind is served in the group version. A kind
+// missing from cached discovery results invalidates the cache and is looked up again,
+// as it may have been installed since, e.g. by an operator adding its CRDs.
+func discoverResourceKind(discoveryClient discovery.CachedDiscoveryInterface, groupVersion, kind string) (bool, error) {
+	for invalidated := false; ; invalidated = true {
+		resources, err := discoveryClient.ServerResourcesForGroupVersion(groupVersion)
+		if err != nil && !errors.IsNotFound(err) && err != memory.ErrCacheNotFound {
+			return false, fmt.Errorf("failed to discover resources for %s: %w", groupVersion, err)
+		}
+		if err == nil {
+			for _, resource := range resources.APIResources {
+				if resource.Kind == kind {
+					return true, nil
+				}
+			}
+		}
+
+		if invalidated || discoveryClient.Fresh() {
+			return false, nil
+		}
+		log.Printf("[DEBUG] Kind %s not found in cached discovery of %s, invalidating the discovery cache", kind, groupVersion)
+		discoveryClient.Invalidate()
+	}
+}
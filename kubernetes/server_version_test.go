@@ -0,0 +1,176 @@
+package kubernetes
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+)
+
+func Test_parseServerVersion(t *testing.T) {
+	tests := []struct {
+		name  string
+		given k8sversion.Info
+		then  serverVersion
+	}{
+		{
+			"validate upstream version",
+			k8sversion.Info{Major: "1", Minor: "27", GitVersion: "v1.27.3"},
+			serverVersion{Major: 1, Minor: 27, Patch: 3},
+		},
+		{
+			"validate kind version",
+			k8sversion.Info{Major: "1", Minor: "29", GitVersion: "v1.29.2"},
+			serverVersion{Major: 1, Minor: 29, Patch: 2},
+		},
+		{
+			"validate aks version",
+			k8sversion.Info{Major: "1", Minor: "27", GitVersion: "v1.27.7"},
+			serverVersion{Major: 1, Minor: 27, Patch: 7},
+		},
+		{
+			"validate eks version",
+			k8sversion.Info{Major: "1", Minor: "27+", GitVersion: "v1.27.4-eks-2d98532"},
+			serverVersion{Major: 1, Minor: 27, Patch: 4, Prerelease: "eks-2d98532", Distribution: "eks"},
+		},
+		{
+			"validate gke version",
+			k8sversion.Info{Major: "1", Minor: "27", GitVersion: "v1.27.3-gke.100"},
+			serverVersion{Major: 1, Minor: 27, Patch: 3, Prerelease: "gke.100", Distribution: "gke"},
+		},
+		{
+			"validate k3s version",
+			k8sversion.Info{Major: "1", Minor: "20", GitVersion: "v1.20.0+k3s2"},
+			serverVersion{Major: 1, Minor: 20, Patch: 0, BuildMetadata: "k3s2", Distribution: "k3s"},
+		},
+		{
+			"validate rke2 version",
+			k8sversion.Info{Major: "1", Minor: "26", GitVersion: "v1.26.8+rke2r1"},
+			serverVersion{Major: 1, Minor: 26, Patch: 8, BuildMetadata: "rke2r1", Distribution: "rke2"},
+		},
+		{
+			"validate openshift version",
+			k8sversion.Info{Major: "1", Minor: "27", GitVersion: "v1.27.6+f67aeb3"},
+			serverVersion{Major: 1, Minor: 27, Patch: 6, BuildMetadata: "f67aeb3"},
+		},
+		{
+			"validate prerelease version",
+			k8sversion.Info{Major: "1", Minor: "28", GitVersion: "v1.28.0-rc.1"},
+			serverVersion{Major: 1, Minor: 28, Patch: 0, Prerelease: "rc.1"},
+		},
+		{
+			"validate fallback to major and minor",
+			k8sversion.Info{Major: "1", Minor: "16+", GitVersion: "unknown"},
+			serverVersion{Major: 1, Minor: 16},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseServerVersion(&tt.given)
+			if err != nil {
+				t.Fatalf("parseServerVersion() error = %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.then) {
+				t.Errorf("parseServerVersion() = %+v, want %+v", *got, tt.then)
+			}
+		})
+	}
+}
+
+func Test_parseServerVersionInvalid(t *testing.T) {
+	if _, err := parseServerVersion(&k8sversion.Info{Major: "", Minor: "", GitVersion: "unknown"}); err == nil {
+		t.Errorf("parseServerVersion() expected an error for an unparsable version")
+	}
+}
+
+func Test_serverVersionSatisfies(t *testing.T) {
+	tests := []struct {
+		name       string
+		given      k8sversion.Info
+		constraint string
+		then       bool
+	}{
+		{
+			"validate version within range",
+			k8sversion.Info{GitVersion: "v1.27.3"},
+			">= 1.21, < 1.28",
+			true,
+		},
+		{
+			"validate version outside of range",
+			k8sversion.Info{GitVersion: "v1.28.0"},
+			">= 1.21, < 1.28",
+			false,
+		},
+		{
+			"validate vendor prerelease is ignored",
+			k8sversion.Info{GitVersion: "v1.27.4-eks-2d98532"},
+			">= 1.27",
+			true,
+		},
+		{
+			"validate vendor build metadata is ignored",
+			k8sversion.Info{GitVersion: "v1.20.0+k3s2"},
+			"= 1.20.0",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := parseServerVersion(&tt.given)
+			if err != nil {
+				t.Fatalf("parseServerVersion() error = %v", err)
+			}
+			got, err := v.Satisfies(tt.constraint)
+			if err != nil {
+				t.Fatalf("Satisfies() error = %v", err)
+			}
+			if got != tt.then {
+				t.Errorf("Satisfies(%q) = %v, want %v", tt.constraint, got, tt.then)
+			}
+		})
+	}
+}
+
+// staleDiscovery serves resources without the CRD kind until it is invalidated.
+type staleDiscovery struct {
+	discovery.DiscoveryInterface
+	fresh       bool
+	invalidated int
+}
+
+func (d *staleDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	resources := &metav1.APIResourceList{GroupVersion: groupVersion}
+	if d.fresh {
+		resources.APIResources = append(resources.APIResources, metav1.APIResource{Kind: "Certificate"})
+	}
+	return resources, nil
+}
+
+func (d *staleDiscovery) Fresh() bool { return d.fresh }
+
+func (d *staleDiscovery) Invalidate() {
+	d.invalidated++
+	d.fresh = true
+}
+
+func Test_discoverResourceKind(t *testing.T) {
+	client := &staleDiscovery{}
+	served, err := discoverResourceKind(client, "cert-manager.io/v1", "Certificate")
+	if err != nil {
+		t.Fatalf("discoverResourceKind() error = %v", err)
+	}
+	if !served || client.invalidated != 1 {
+		t.Errorf("discoverResourceKind() = %v after %d invalidations, want served after 1", served, client.invalidated)
+	}
+
+	served, err = discoverResourceKind(client, "cert-manager.io/v1", "Issuer")
+	if err != nil {
+		t.Fatalf("discoverResourceKind() error = %v", err)
+	}
+	if served || client.invalidated != 1 {
+		t.Errorf("discoverResourceKind() = %v after %d invalidations, want not served without invalidating a fresh cache", served, client.invalidated)
+	}
+}
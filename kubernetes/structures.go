@@ -1,5 +1,10 @@
 package kubernetes
 
+import (
+	"fmt"
+	"time"
+)
+
 func expandStringSlice(s []interface{}) []string {
 	result := make([]string, len(s), len(s))
 	for k, v := range s {
@@ -12,3 +17,23 @@ func expandStringSlice(s []interface{}) []string {
 	}
 	return result
 }
+
+func validateDuration(v interface{}, k string) (ws []string, es []error) {
+	if _, err := time.ParseDuration(v.(string)); err != nil {
+		es = append(es, fmt.Errorf("%q: %s is not a valid duration, e.g. 30s or 10m", k, v))
+	}
+	return
+}
+
+// validateDurationAtLeast validates a duration of at least min.
+func validateDurationAtLeast(min time.Duration) func(v interface{}, k string) ([]string, []error) {
+	return func(v interface{}, k string) (ws []string, es []error) {
+		duration, err := time.ParseDuration(v.(string))
+		if err != nil {
+			es = append(es, fmt.Errorf("%q: %s is not a valid duration, e.g. 30s or 10m", k, v))
+		} else if duration < min {
+			es = append(es, fmt.Errorf("%q: %s is shorter than %s", k, v, min))
+		}
+		return
+	}
+}
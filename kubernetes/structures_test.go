@@ -3,6 +3,7 @@ package kubernetes
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_expandStringSlice(t *testing.T) {
@@ -38,3 +39,24 @@ func Test_expandStringSlice(t *testing.T) {
 		})
 	}
 }
+
+func Test_validateDurationAtLeast(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+		then  int
+	}{
+		{"validate duration", "1h", 0},
+		{"validate minimum", "1s", 0},
+		{"validate shorter duration", "500ms", 1},
+		{"validate negative duration", "-5m", 1},
+		{"validate invalid duration", "an hour", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, es := validateDurationAtLeast(time.Second)(tt.given, "since"); len(es) != tt.then {
+				t.Errorf("validateDurationAtLeast() = %v, want %d errors", es, tt.then)
+			}
+		})
+	}
+}
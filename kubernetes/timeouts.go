@@ -0,0 +1,61 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const defaultReadTimeout = 20 * time.Minute
+
+// queryResourceTimeouts are the timeouts of the query resources. Only read is
+// configurable, creating a query resource being a read.
+func queryResourceTimeouts() *schema.ResourceTimeout {
+	return &schema.ResourceTimeout{
+		Read: schema.DefaultTimeout(defaultReadTimeout),
+	}
+}
+
+// createWithReadTimeout creates a query resource by reading it within its read timeout.
+func createWithReadTimeout(read schema.ReadContextFunc) schema.CreateContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutRead))
+		defer cancel()
+		return read(ctx, d, meta)
+	}
+}
+
+// dataSourceTimeoutsSchema is the timeouts block of the data sources. The SDK
+// ignores the timeouts of data sources, so they declare the block themselves.
+func dataSourceTimeoutsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"read": {
+					Type:         schema.TypeString,
+					Optional:     true,
+					ValidateFunc: validateDuration,
+				},
+			},
+		},
+	}
+}
+
+// readWithDataSourceTimeout bounds the read of a data source by the read timeout of its timeouts block.
+func readWithDataSourceTimeout(read schema.ReadContextFunc) schema.ReadContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+		timeout := defaultReadTimeout
+		if v, ok := d.GetOk("timeouts.0.read"); ok {
+			// validated by the schema
+			timeout, _ = time.ParseDuration(v.(string))
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return read(ctx, d, meta)
+	}
+}
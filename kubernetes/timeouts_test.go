@@ -0,0 +1,50 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func Test_readWithDataSourceTimeout(t *testing.T) {
+	tests := []struct {
+		name  string
+		given map[string]interface{}
+		then  time.Duration
+	}{
+		{
+			"validate the default read timeout",
+			map[string]interface{}{},
+			defaultReadTimeout,
+		},
+		{
+			"validate the read timeout of the timeouts block",
+			map[string]interface{}{"timeouts": []interface{}{map[string]interface{}{"read": "2m"}}},
+			2 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var remaining time.Duration
+			read := readWithDataSourceTimeout(func(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+				deadline, ok := ctx.Deadline()
+				if !ok {
+					t.Fatal("read context has no deadline")
+				}
+				remaining = time.Until(deadline)
+				return nil
+			})
+
+			d := schema.TestResourceDataRaw(t, dataSourceKubectlPods().Schema, tt.given)
+			if diags := read(context.Background(), d, nil); diags.HasError() {
+				t.Fatalf("read() = %v", diags)
+			}
+			if remaining > tt.then || remaining < tt.then-time.Minute {
+				t.Errorf("read deadline in %s, want %s", remaining, tt.then)
+			}
+		})
+	}
+}